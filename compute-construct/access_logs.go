@@ -0,0 +1,96 @@
+package breezeware
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	elbv2 "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// ContainerComputeAccessLogsProps sends the load balancer's access logs to S3
+// under Prefix. Without a BucketName a bucket is created with SSE-S3
+// encryption, the only kind ELB log delivery supports, and the lifecycle rules
+// below. A day count of zero leaves that rule out. A BucketName is left as it
+// is: its owner must already grant s3:PutObject on Prefix to the region's ELB
+// account and to delivery.logs.amazonaws.com, or ELB refuses to turn logging
+// on when the stack deploys.
+type ContainerComputeAccessLogsProps struct {
+	Enabled                   bool
+	BucketName                string
+	Prefix                    string
+	InfrequentAccessAfterDays float64
+	GlacierAfterDays          float64
+	ExpirationDays            float64
+	RemovalPolicy             awscdk.RemovalPolicy
+}
+
+func (p *ContainerComputeAccessLogsProps) lifecycleEnabled() bool {
+	return p.InfrequentAccessAfterDays > 0 || p.GlacierAfterDays > 0 || p.ExpirationDays > 0
+}
+
+func createAccessLogBucket(scope constructs.Construct, id *string, props *ContainerComputeAccessLogsProps) awss3.IBucket {
+	if props.BucketName != "" {
+		// A bucket policy of ours would replace the owner's, so imported
+		// buckets keep theirs and the ELB grants LogAccessLogs adds are dropped.
+		return awss3.Bucket_FromBucketName(scope, id, jsii.String(props.BucketName))
+	}
+
+	removalPolicy := props.RemovalPolicy
+	if removalPolicy == "" {
+		removalPolicy = awscdk.RemovalPolicy_RETAIN
+	}
+
+	var transitions []*awss3.Transition
+	if props.InfrequentAccessAfterDays > 0 {
+		transitions = append(transitions, &awss3.Transition{
+			StorageClass:    awss3.StorageClass_INFREQUENT_ACCESS(),
+			TransitionAfter: awscdk.Duration_Days(jsii.Number(props.InfrequentAccessAfterDays)),
+		})
+	}
+	if props.GlacierAfterDays > 0 {
+		transitions = append(transitions, &awss3.Transition{
+			StorageClass:    awss3.StorageClass_GLACIER(),
+			TransitionAfter: awscdk.Duration_Days(jsii.Number(props.GlacierAfterDays)),
+		})
+	}
+
+	bucketProps := &awss3.BucketProps{
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		EnforceSSL:        jsii.Bool(true),
+		RemovalPolicy:     removalPolicy,
+		// A bucket that still holds logs cannot be deleted with its stack.
+		AutoDeleteObjects: jsii.Bool(removalPolicy == awscdk.RemovalPolicy_DESTROY),
+	}
+	if props.lifecycleEnabled() {
+		rule := &awss3.LifecycleRule{
+			AbortIncompleteMultipartUploadAfter: awscdk.Duration_Days(jsii.Number(7)),
+		}
+		if len(transitions) > 0 {
+			rule.Transitions = &transitions
+		}
+		if props.ExpirationDays > 0 {
+			rule.Expiration = awscdk.Duration_Days(jsii.Number(props.ExpirationDays))
+		}
+		bucketProps.LifecycleRules = &[]*awss3.LifecycleRule{rule}
+	}
+	return awss3.NewBucket(scope, id, bucketProps)
+}
+
+// configureAccessLogs points lb at bucket. CDK adds the policy statements for
+// the region's ELB account. The region has to be known
+// at synth time to pick that account.
+func configureAccessLogs(lb elbv2.ApplicationLoadBalancer, bucket awss3.IBucket, props *ContainerComputeAccessLogsProps) {
+	if *awscdk.Token_IsUnresolved(awscdk.Stack_Of(lb).Region()) {
+		lb.Node().AddValidation(&propsValidation{validate: func() []string {
+			return []string{"LoadBalancer.AccessLogs needs a stack with an explicit region"}
+		}})
+		return
+	}
+	lb.LogAccessLogs(bucket, jsii.String(props.Prefix))
+	// ELB checks that it can write to the bucket when logging is turned on.
+	if policy := bucket.Policy(); policy != nil {
+		lb.Node().AddDependency(policy)
+	}
+}
@@ -0,0 +1,142 @@
+package breezeware
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// elbLogDeliveryStatement matches the bucket policy statement that lets the
+// us-east-1 ELB account 127311923021 write access logs.
+var elbLogDeliveryStatement = assertions.Match_ObjectLike(&map[string]interface{}{
+	"Action": assertions.Match_ArrayWith(&[]interface{}{"s3:PutObject"}),
+	"Effect": "Allow",
+	"Principal": map[string]interface{}{
+		"AWS": map[string]interface{}{
+			"Fn::Join": []interface{}{"", []interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, ":iam::127311923021:root"}},
+		},
+	},
+})
+
+func TestAccessLogs(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          string
+		loadBalancer string
+		prefix       string
+		check        func(t *testing.T, template assertions.Template)
+	}{
+		{
+			name:         "created bucket with lifecycle",
+			env:          "prod",
+			loadBalancer: "    accessLogs:\n      enabled: true\n      infrequentAccessAfterDays: 30\n      glacierAfterDays: 90\n      expirationDays: 365",
+			prefix:       "prod",
+			check: func(t *testing.T, template assertions.Template) {
+				template.HasResource(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+					"DeletionPolicy": "Retain",
+					"Properties": map[string]interface{}{
+						"BucketEncryption": map[string]interface{}{
+							"ServerSideEncryptionConfiguration": []interface{}{
+								map[string]interface{}{"ServerSideEncryptionByDefault": map[string]interface{}{"SSEAlgorithm": "AES256"}},
+							},
+						},
+						"PublicAccessBlockConfiguration": map[string]interface{}{
+							"BlockPublicAcls":       true,
+							"BlockPublicPolicy":     true,
+							"IgnorePublicAcls":      true,
+							"RestrictPublicBuckets": true,
+						},
+						"LifecycleConfiguration": map[string]interface{}{
+							"Rules": []interface{}{
+								map[string]interface{}{
+									"AbortIncompleteMultipartUpload": map[string]interface{}{"DaysAfterInitiation": 7},
+									"ExpirationInDays":               365,
+									"Status":                         "Enabled",
+									"Transitions": []interface{}{
+										map[string]interface{}{"StorageClass": "STANDARD_IA", "TransitionInDays": 30},
+										map[string]interface{}{"StorageClass": "GLACIER", "TransitionInDays": 90},
+									},
+								},
+							},
+						},
+					},
+				})
+				template.HasResourceProperties(jsii.String("AWS::S3::BucketPolicy"), map[string]interface{}{
+					"PolicyDocument": map[string]interface{}{
+						"Statement": assertions.Match_ArrayWith(&[]interface{}{elbLogDeliveryStatement}),
+					},
+				})
+				template.ResourceCountIs(jsii.String("Custom::S3AutoDeleteObjects"), jsii.Number(0))
+			},
+		},
+		{
+			name:         "created bucket in dev",
+			env:          "dev",
+			loadBalancer: "    accessLogs:\n      enabled: true\n      prefix: alb",
+			prefix:       "dev/alb",
+			check: func(t *testing.T, template assertions.Template) {
+				template.HasResource(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+					"DeletionPolicy": "Delete",
+				})
+				template.ResourceCountIs(jsii.String("Custom::S3AutoDeleteObjects"), jsii.Number(1))
+			},
+		},
+		{
+			name:         "existing bucket",
+			env:          "staging",
+			loadBalancer: "    accessLogs:\n      enabled: true\n      bucket: central-alb-logs",
+			prefix:       "staging",
+			check: func(t *testing.T, template assertions.Template) {
+				template.ResourceCountIs(jsii.String("AWS::S3::Bucket"), jsii.Number(0))
+				template.ResourceCountIs(jsii.String("AWS::S3::BucketPolicy"), jsii.Number(0))
+				template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+					"LoadBalancerAttributes": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Key": "access_logs.s3.bucket", "Value": "central-alb-logs"},
+					}),
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := synthConfigCompute(t, tt.env, tt.loadBalancer)
+
+			for key, value := range map[string]interface{}{
+				"access_logs.s3.enabled": "true",
+				"access_logs.s3.prefix":  tt.prefix,
+				"access_logs.s3.bucket":  assertions.Match_AnyValue(),
+			} {
+				template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+					"LoadBalancerAttributes": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Key": key, "Value": value},
+					}),
+				})
+			}
+			tt.check(t, template)
+		})
+	}
+}
+
+func TestAccessLogsWithoutRegion(t *testing.T) {
+	// GIVEN
+	stack := awscdk.NewStack(awscdk.NewApp(nil), jsii.String("TestStack"), nil)
+	props := testComputeProps()
+	props.LoadBalancer.AccessLogs = ContainerComputeAccessLogsProps{Enabled: true}
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	want := "LoadBalancer.AccessLogs needs a stack with an explicit region"
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
@@ -0,0 +1,898 @@
+package breezeware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	ecs "github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	elbv2 "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	iam "github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/constructs-go/constructs/v10"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvironmentContextKey = "env"
+	DefaultEnvironment    = "dev"
+)
+
+var configExtensions = []string{".yaml", ".yml", ".json"}
+
+var instanceClasses = strings.Fields(`
+	m3 m4 m5 m5d m5a m5ad m5n m5dn m5zn r3 r4 r5 r6a r6i r6id r5n r5d
+	r5dn r5a r5ad r5b r6g r6gd c3 c4 c5 c5d c5a c5ad c5n c6i c6id c6in
+	c6a c6g c7g c6gd c6gn d2 d3 d3en h1 i3 i3en i4i im4gn is4gen
+	t2 t3 t3a t4g x1 x1e x2g x2gd x2iedn x2idn x2iezn f1 g3s g3
+	g4dn g4ad g5 g5g p2 p3 p3dn p4de p4d a1 m6g m6i m6id m6a m6gd
+	z1d inf1 mac1 vt1 hpc6a dl1
+`)
+
+var instanceSizes = map[string]ec2.InstanceSize{
+	"nano":     ec2.InstanceSize_NANO,
+	"micro":    ec2.InstanceSize_MICRO,
+	"small":    ec2.InstanceSize_SMALL,
+	"medium":   ec2.InstanceSize_MEDIUM,
+	"large":    ec2.InstanceSize_LARGE,
+	"xlarge":   ec2.InstanceSize_XLARGE,
+	"2xlarge":  ec2.InstanceSize_XLARGE2,
+	"3xlarge":  ec2.InstanceSize_XLARGE3,
+	"4xlarge":  ec2.InstanceSize_XLARGE4,
+	"6xlarge":  ec2.InstanceSize_XLARGE6,
+	"8xlarge":  ec2.InstanceSize_XLARGE8,
+	"9xlarge":  ec2.InstanceSize_XLARGE9,
+	"10xlarge": ec2.InstanceSize_XLARGE10,
+	"12xlarge": ec2.InstanceSize_XLARGE12,
+	"16xlarge": ec2.InstanceSize_XLARGE16,
+	"18xlarge": ec2.InstanceSize_XLARGE18,
+	"24xlarge": ec2.InstanceSize_XLARGE24,
+	"32xlarge": ec2.InstanceSize_XLARGE32,
+	"48xlarge": ec2.InstanceSize_XLARGE48,
+	"56xlarge": ec2.InstanceSize_XLARGE56,
+	"metal":    ec2.InstanceSize_METAL,
+}
+
+var subnetTypes = map[string]ec2.SubnetType{
+	"public":   ec2.SubnetType_PUBLIC,
+	"private":  ec2.SubnetType_PRIVATE_WITH_EGRESS,
+	"isolated": ec2.SubnetType_PRIVATE_ISOLATED,
+}
+
+var machineImageFamilies = map[string]MachineImageFamily{
+	"al2":          MachineImageEcsAmazonLinux2,
+	"al2023":       MachineImageEcsAmazonLinux2023,
+	"bottlerocket": MachineImageBottlerocket,
+	"custom":       MachineImageCustom,
+}
+
+var spotAllocationStrategies = map[string]autoscaling.SpotAllocationStrategy{
+	"lowest-price":                   autoscaling.SpotAllocationStrategy_LOWEST_PRICE,
+	"capacity-optimized":             autoscaling.SpotAllocationStrategy_CAPACITY_OPTIMIZED,
+	"capacity-optimized-prioritized": autoscaling.SpotAllocationStrategy_CAPACITY_OPTIMIZED_PRIORITIZED,
+	"price-capacity-optimized":       autoscaling.SpotAllocationStrategy_PRICE_CAPACITY_OPTIMIZED,
+}
+
+var instanceAccessModes = map[string]InstanceAccessMode{
+	"ssm":  InstanceAccessSessionManager,
+	"ssh":  InstanceAccessSsh,
+	"none": InstanceAccessNone,
+}
+
+var defaultActionTypes = map[string]DefaultActionType{
+	"fixed-response": DefaultActionFixedResponse,
+	"redirect":       DefaultActionRedirect,
+	"forward":        DefaultActionForward,
+}
+
+// sslPolicies leaves out the policies that still accept TLS 1.0 or 1.1.
+var sslPolicies = map[string]elbv2.SslPolicy{
+	"recommended-tls":  elbv2.SslPolicy_RECOMMENDED_TLS,
+	"tls13-res":        elbv2.SslPolicy_TLS13_RES,
+	"tls13-ext1":       elbv2.SslPolicy_TLS13_EXT1,
+	"tls13-ext2":       elbv2.SslPolicy_TLS13_EXT2,
+	"tls13-13":         elbv2.SslPolicy_TLS13_13,
+	"tls12":            elbv2.SslPolicy_TLS12,
+	"tls12-ext":        elbv2.SslPolicy_TLS12_EXT,
+	"fs-tls12":         elbv2.SslPolicy_FORWARD_SECRECY_TLS12,
+	"fs-tls12-res":     elbv2.SslPolicy_FORWARD_SECRECY_TLS12_RES,
+	"fs-tls12-res-gcm": elbv2.SslPolicy_FORWARD_SECRECY_TLS12_RES_GCM,
+}
+
+var desyncMitigationModes = map[string]elbv2.DesyncMitigationMode{
+	"monitor":   elbv2.DesyncMitigationMode_MONITOR,
+	"defensive": elbv2.DesyncMitigationMode_DEFENSIVE,
+	"strictest": elbv2.DesyncMitigationMode_STRICTEST,
+}
+
+var volumeScopes = map[string]ecs.Scope{
+	"task":   ecs.Scope_TASK,
+	"shared": ecs.Scope_SHARED,
+}
+
+var logRetentions = map[string]awslogs.RetentionDays{
+	"1d":  awslogs.RetentionDays_ONE_DAY,
+	"3d":  awslogs.RetentionDays_THREE_DAYS,
+	"5d":  awslogs.RetentionDays_FIVE_DAYS,
+	"1w":  awslogs.RetentionDays_ONE_WEEK,
+	"2w":  awslogs.RetentionDays_TWO_WEEKS,
+	"1m":  awslogs.RetentionDays_ONE_MONTH,
+	"2m":  awslogs.RetentionDays_TWO_MONTHS,
+	"3m":  awslogs.RetentionDays_THREE_MONTHS,
+	"6m":  awslogs.RetentionDays_SIX_MONTHS,
+	"1y":  awslogs.RetentionDays_ONE_YEAR,
+	"inf": awslogs.RetentionDays_INFINITE,
+}
+
+type EnvironmentConfig struct {
+	Environment Environment
+	Compute     ContainerComputeProps
+	Services    []ContainerServiceProps
+}
+
+type environmentFile struct {
+	Environment environmentSettings `yaml:"environment" json:"environment"`
+	Compute     computeConfig       `yaml:"compute" json:"compute"`
+	Services    []serviceConfig     `yaml:"services" json:"services"`
+}
+
+type environmentSettings struct {
+	Account       string            `yaml:"account" json:"account"`
+	Region        string            `yaml:"region" json:"region"`
+	NamePrefix    *string           `yaml:"namePrefix" json:"namePrefix"`
+	RemovalPolicy string            `yaml:"removalPolicy" json:"removalPolicy"`
+	Tags          map[string]string `yaml:"tags" json:"tags"`
+}
+
+type computeConfig struct {
+	VpcId          *string              `yaml:"vpcId" json:"vpcId"`
+	Vpc            vpcConfig            `yaml:"vpc" json:"vpc"`
+	VpcEndpoints   vpcEndpointsConfig   `yaml:"vpcEndpoints" json:"vpcEndpoints"`
+	Cluster        clusterConfig        `yaml:"cluster" json:"cluster"`
+	AutoScaling    []asgConfig          `yaml:"autoScalingGroups" json:"autoScalingGroups"`
+	LoadBalancer   loadBalancerConfig   `yaml:"loadBalancer" json:"loadBalancer"`
+	Namespace      namespaceConfig      `yaml:"cloudMapNamespace" json:"cloudMapNamespace"`
+	SessionLogging sessionLoggingConfig `yaml:"sessionLogging" json:"sessionLogging"`
+}
+
+type sessionLoggingConfig struct {
+	DocumentName string `yaml:"documentName" json:"documentName"`
+	S3           bool   `yaml:"s3" json:"s3"`
+	CloudWatch   bool   `yaml:"cloudWatch" json:"cloudWatch"`
+	LogGroupName string `yaml:"logGroupName" json:"logGroupName"`
+	LogRetention string `yaml:"logRetention" json:"logRetention"`
+}
+
+type vpcConfig struct {
+	Name        string  `yaml:"name" json:"name"`
+	Cidr        string  `yaml:"cidr" json:"cidr"`
+	MaxAzs      float64 `yaml:"maxAzs" json:"maxAzs"`
+	NatGateways float64 `yaml:"natGateways" json:"natGateways"`
+}
+
+type vpcEndpointsConfig struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	SubnetType string `yaml:"subnetType" json:"subnetType"`
+}
+
+type clusterConfig struct {
+	Name                            string                   `yaml:"name" json:"name"`
+	ContainerInsights               bool                     `yaml:"containerInsights" json:"containerInsights"`
+	Fargate                         *bool                    `yaml:"fargate" json:"fargate"`
+	DefaultCapacityProviderStrategy []capacityProviderConfig `yaml:"defaultCapacityProviderStrategy" json:"defaultCapacityProviderStrategy"`
+}
+
+type capacityProviderConfig struct {
+	CapacityProvider string  `yaml:"capacityProvider" json:"capacityProvider"`
+	Base             float64 `yaml:"base" json:"base"`
+	Weight           float64 `yaml:"weight" json:"weight"`
+}
+
+type asgConfig struct {
+	Name                    string                `yaml:"name" json:"name"`
+	CapacityProvider        string                `yaml:"capacityProvider" json:"capacityProvider"`
+	InstanceType            string                `yaml:"instanceType" json:"instanceType"`
+	MinCapacity             float64               `yaml:"minCapacity" json:"minCapacity"`
+	MaxCapacity             *float64              `yaml:"maxCapacity" json:"maxCapacity"`
+	DesiredCapacity         float64               `yaml:"desiredCapacity" json:"desiredCapacity"`
+	Access                  accessConfig          `yaml:"access" json:"access"`
+	Role                    roleConfig            `yaml:"role" json:"role"`
+	VolumePlugins           []volumePluginConfig  `yaml:"volumePlugins" json:"volumePlugins"`
+	SubnetType              string                `yaml:"subnetType" json:"subnetType"`
+	MachineImage            machineImageConfig    `yaml:"machineImage" json:"machineImage"`
+	EcsAgent                ecsAgentConfig        `yaml:"ecsAgent" json:"ecsAgent"`
+	MixedInstances          *mixedInstancesConfig `yaml:"mixedInstances" json:"mixedInstances"`
+	AllowImdsv1             bool                  `yaml:"allowImdsv1" json:"allowImdsv1"`
+	MetadataHopLimit        float64               `yaml:"metadataHopLimit" json:"metadataHopLimit"`
+	DetailedMonitoring      bool                  `yaml:"detailedMonitoring" json:"detailedMonitoring"`
+	RootVolume              rootVolumeConfig      `yaml:"rootVolume" json:"rootVolume"`
+	ManagedScaling          managedScalingConfig  `yaml:"managedScaling" json:"managedScaling"`
+	BlockInstanceRoleAccess bool                  `yaml:"blockInstanceRoleAccess" json:"blockInstanceRoleAccess"`
+}
+
+type managedScalingConfig struct {
+	TargetCapacityPercent        float64 `yaml:"targetCapacityPercent" json:"targetCapacityPercent"`
+	MinimumScalingStepSize       float64 `yaml:"minimumScalingStepSize" json:"minimumScalingStepSize"`
+	MaximumScalingStepSize       float64 `yaml:"maximumScalingStepSize" json:"maximumScalingStepSize"`
+	InstanceWarmupSeconds        float64 `yaml:"instanceWarmupSeconds" json:"instanceWarmupSeconds"`
+	ManagedTerminationProtection bool    `yaml:"managedTerminationProtection" json:"managedTerminationProtection"`
+}
+
+type rootVolumeConfig struct {
+	SizeGiB    float64 `yaml:"sizeGiB" json:"sizeGiB"`
+	VolumeType string  `yaml:"volumeType" json:"volumeType"`
+	Iops       float64 `yaml:"iops" json:"iops"`
+	KmsKeyArn  string  `yaml:"kmsKeyArn" json:"kmsKeyArn"`
+}
+
+type mixedInstancesConfig struct {
+	InstanceTypes          []string `yaml:"instanceTypes" json:"instanceTypes"`
+	OnDemandBaseCapacity   float64  `yaml:"onDemandBaseCapacity" json:"onDemandBaseCapacity"`
+	SpotPercentage         float64  `yaml:"spotPercentage" json:"spotPercentage"`
+	SpotAllocationStrategy string   `yaml:"spotAllocationStrategy" json:"spotAllocationStrategy"`
+}
+
+type ecsAgentConfig struct {
+	SpotInstanceDraining        bool    `yaml:"spotInstanceDraining" json:"spotInstanceDraining"`
+	ImagePullBehavior           string  `yaml:"imagePullBehavior" json:"imagePullBehavior"`
+	ContainerStopTimeoutSeconds float64 `yaml:"containerStopTimeoutSeconds" json:"containerStopTimeoutSeconds"`
+	TaskIamRole                 bool    `yaml:"taskIamRole" json:"taskIamRole"`
+	AllowAwsvpcImds             bool    `yaml:"allowAwsvpcImds" json:"allowAwsvpcImds"`
+}
+
+type machineImageConfig struct {
+	Family       string `yaml:"family" json:"family"`
+	AmiId        string `yaml:"amiId" json:"amiId"`
+	SsmParameter string `yaml:"ssmParameter" json:"ssmParameter"`
+}
+
+type loadBalancerConfig struct {
+	Name               string              `yaml:"name" json:"name"`
+	CertificateArn     string              `yaml:"certificateArn" json:"certificateArn"`
+	CertificateArns    []string            `yaml:"certificateArns" json:"certificateArns"`
+	HostedZone         hostedZoneConfig    `yaml:"hostedZone" json:"hostedZone"`
+	CertificateDomains []string            `yaml:"certificateDomains" json:"certificateDomains"`
+	Internal           bool                `yaml:"internal" json:"internal"`
+	SubnetType         string              `yaml:"subnetType" json:"subnetType"`
+	SubnetIds          []string            `yaml:"subnetIds" json:"subnetIds"`
+	AllowedCidrs       []string            `yaml:"allowedCidrs" json:"allowedCidrs"`
+	AllowedPrefixLists []string            `yaml:"allowedPrefixLists" json:"allowedPrefixLists"`
+	DefaultAction      defaultActionConfig `yaml:"defaultAction" json:"defaultAction"`
+	Hardening          hardeningConfig     `yaml:"hardening" json:"hardening"`
+	AccessLogs         accessLogsConfig    `yaml:"accessLogs" json:"accessLogs"`
+}
+
+type accessLogsConfig struct {
+	Enabled                   bool    `yaml:"enabled" json:"enabled"`
+	Bucket                    string  `yaml:"bucket" json:"bucket"`
+	Prefix                    string  `yaml:"prefix" json:"prefix"`
+	InfrequentAccessAfterDays float64 `yaml:"infrequentAccessAfterDays" json:"infrequentAccessAfterDays"`
+	GlacierAfterDays          float64 `yaml:"glacierAfterDays" json:"glacierAfterDays"`
+	ExpirationDays            float64 `yaml:"expirationDays" json:"expirationDays"`
+}
+
+// hardeningConfig overrides the environment's load balancer hardening preset.
+// Fields that are left out keep the preset's value.
+type hardeningConfig struct {
+	SslPolicy               string   `yaml:"sslPolicy" json:"sslPolicy"`
+	DeletionProtection      *bool    `yaml:"deletionProtection" json:"deletionProtection"`
+	Http2                   *bool    `yaml:"http2" json:"http2"`
+	DropInvalidHeaderFields *bool    `yaml:"dropInvalidHeaderFields" json:"dropInvalidHeaderFields"`
+	DesyncMitigationMode    string   `yaml:"desyncMitigationMode" json:"desyncMitigationMode"`
+	IdleTimeoutSeconds      *float64 `yaml:"idleTimeoutSeconds" json:"idleTimeoutSeconds"`
+}
+
+type hostedZoneConfig struct {
+	Id   string `yaml:"id" json:"id"`
+	Name string `yaml:"name" json:"name"`
+}
+
+type defaultActionConfig struct {
+	Type            string  `yaml:"type" json:"type"`
+	StatusCode      float64 `yaml:"statusCode" json:"statusCode"`
+	ContentType     string  `yaml:"contentType" json:"contentType"`
+	MessageBody     string  `yaml:"messageBody" json:"messageBody"`
+	Host            string  `yaml:"host" json:"host"`
+	Path            string  `yaml:"path" json:"path"`
+	Port            string  `yaml:"port" json:"port"`
+	Protocol        string  `yaml:"protocol" json:"protocol"`
+	Query           string  `yaml:"query" json:"query"`
+	Permanent       bool    `yaml:"permanent" json:"permanent"`
+	Service         string  `yaml:"service" json:"service"`
+	HealthCheckPath string  `yaml:"healthCheckPath" json:"healthCheckPath"`
+}
+
+type accessConfig struct {
+	Mode            string   `yaml:"mode" json:"mode"`
+	SshKeyName      string   `yaml:"sshKeyName" json:"sshKeyName"`
+	SshAllowedCidrs []string `yaml:"sshAllowedCidrs" json:"sshAllowedCidrs"`
+}
+
+// roleConfig takes inline policies as IAM JSON policy documents keyed by
+// policy name.
+type roleConfig struct {
+	ManagedPolicyArns      []string                          `yaml:"managedPolicyArns" json:"managedPolicyArns"`
+	InlinePolicies         map[string]map[string]interface{} `yaml:"inlinePolicies" json:"inlinePolicies"`
+	PermissionsBoundaryArn string                            `yaml:"permissionsBoundaryArn" json:"permissionsBoundaryArn"`
+}
+
+type volumePluginConfig struct {
+	Name     string            `yaml:"name" json:"name"`
+	Alias    string            `yaml:"alias" json:"alias"`
+	Settings map[string]string `yaml:"settings" json:"settings"`
+}
+
+type namespaceConfig struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+type serviceConfig struct {
+	Name               string            `yaml:"name" json:"name"`
+	Image              string            `yaml:"image" json:"image"`
+	DesiredCount       *float64          `yaml:"desiredCount" json:"desiredCount"`
+	Cpu                float64           `yaml:"cpu" json:"cpu"`
+	MemoryLimitMiB     float64           `yaml:"memoryLimitMiB" json:"memoryLimitMiB"`
+	ContainerPort      float64           `yaml:"containerPort" json:"containerPort"`
+	Environment        map[string]string `yaml:"environment" json:"environment"`
+	LogRetention       string            `yaml:"logRetention" json:"logRetention"`
+	CapacityProvider   string            `yaml:"capacityProvider" json:"capacityProvider"`
+	HealthCheckPath    string            `yaml:"healthCheckPath" json:"healthCheckPath"`
+	Priority           float64           `yaml:"priority" json:"priority"`
+	HostHeaders        []string          `yaml:"hostHeaders" json:"hostHeaders"`
+	PathPatterns       []string          `yaml:"pathPatterns" json:"pathPatterns"`
+	Volumes            []volumeConfig    `yaml:"volumes" json:"volumes"`
+	AvailabilityZone   string            `yaml:"availabilityZone" json:"availabilityZone"`
+	IdleTimeoutSeconds float64           `yaml:"idleTimeoutSeconds" json:"idleTimeoutSeconds"`
+}
+
+type volumeConfig struct {
+	Name          string            `yaml:"name" json:"name"`
+	ContainerPath string            `yaml:"containerPath" json:"containerPath"`
+	ReadOnly      bool              `yaml:"readOnly" json:"readOnly"`
+	Driver        string            `yaml:"driver" json:"driver"`
+	DriverOptions map[string]string `yaml:"driverOptions" json:"driverOptions"`
+	Labels        map[string]string `yaml:"labels" json:"labels"`
+	Scope         string            `yaml:"scope" json:"scope"`
+	Autoprovision bool              `yaml:"autoprovision" json:"autoprovision"`
+}
+
+// LoadEnvironmentConfigs loads the config of every environment selected with
+// the "env" context key (`cdk synth -c env=staging` or `-c env=dev,prod`).
+func LoadEnvironmentConfigs(scope constructs.Construct, dir string) ([]*EnvironmentConfig, error) {
+	var configs []*EnvironmentConfig
+	for _, env := range SelectedEnvironments(scope) {
+		config, err := LoadEnvironmentConfig(dir, env)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// LoadEnvironmentConfig loads <dir>/<env>.yaml, .yml or .json.
+func LoadEnvironmentConfig(dir string, env string) (*EnvironmentConfig, error) {
+	for _, ext := range configExtensions {
+		path := filepath.Join(dir, env+ext)
+		if _, err := os.Stat(path); err == nil {
+			return LoadEnvironmentConfigFile(path)
+		}
+	}
+	return nil, fmt.Errorf("no config file for environment %q in %s (tried %s)", env, dir, strings.Join(configExtensions, ", "))
+}
+
+func LoadEnvironmentConfigFile(path string) (*EnvironmentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file environmentFile
+	switch filepath.Ext(path) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	default:
+		err = fmt.Errorf("unsupported config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	env, err := LookupEnvironment(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	config, errs := file.toEnvironmentConfig(env)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %w", path, newValidationError(errs))
+	}
+	config.Environment.applyTo(config)
+	return config, nil
+}
+
+func (f *environmentFile) toEnvironmentConfig(env Environment) (*EnvironmentConfig, []string) {
+	var errs []string
+	config := &EnvironmentConfig{}
+
+	config.Environment, errs = f.Environment.apply(env)
+
+	compute, computeErrs := f.Compute.toProps()
+	config.Compute = compute
+	errs = append(errs, withPrefix("compute", computeErrs)...)
+
+	hardening, hardeningErrs := f.Compute.LoadBalancer.Hardening.apply(config.Environment.LoadBalancerHardening)
+	config.Compute.LoadBalancer.Hardening = hardening
+	errs = append(errs, hardeningErrs...)
+
+	for i, service := range f.Services {
+		props, serviceErrs := service.toProps()
+		config.Services = append(config.Services, props)
+		errs = append(errs, withPrefix(fmt.Sprintf("services[%d]", i), serviceErrs)...)
+	}
+	return config, errs
+}
+
+func (s *environmentSettings) apply(env Environment) (Environment, []string) {
+	var errs []string
+	env.Account = s.Account
+	env.Region = s.Region
+	if s.NamePrefix != nil {
+		env.NamePrefix = *s.NamePrefix
+	}
+	if s.RemovalPolicy != "" {
+		removalPolicy, ok := removalPolicies[strings.ToLower(s.RemovalPolicy)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("environment.removalPolicy %q is not one of %s", s.RemovalPolicy, strings.Join(sortedKeys(removalPolicies), ", ")))
+		}
+		env.RemovalPolicy = removalPolicy
+	}
+	for key, value := range s.Tags {
+		env.Tags[key] = value
+	}
+	return env, errs
+}
+
+func (c *hardeningConfig) apply(hardening ContainerComputeLoadBalancerHardeningProps) (ContainerComputeLoadBalancerHardeningProps, []string) {
+	var errs []string
+	if c.SslPolicy != "" {
+		sslPolicy, ok := sslPolicies[strings.ToLower(c.SslPolicy)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("compute.loadBalancer.hardening.sslPolicy %q is not one of %s", c.SslPolicy, strings.Join(sortedKeys(sslPolicies), ", ")))
+		}
+		hardening.SslPolicy = sslPolicy
+	}
+	if c.DesyncMitigationMode != "" {
+		mode, ok := desyncMitigationModes[strings.ToLower(c.DesyncMitigationMode)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("compute.loadBalancer.hardening.desyncMitigationMode %q is not one of %s", c.DesyncMitigationMode, strings.Join(sortedKeys(desyncMitigationModes), ", ")))
+		}
+		hardening.DesyncMitigationMode = mode
+	}
+	if c.DeletionProtection != nil {
+		hardening.DeletionProtection = *c.DeletionProtection
+	}
+	if c.Http2 != nil {
+		hardening.DisableHttp2 = !*c.Http2
+	}
+	if c.DropInvalidHeaderFields != nil {
+		hardening.DropInvalidHeaderFields = *c.DropInvalidHeaderFields
+	}
+	if c.IdleTimeoutSeconds != nil {
+		hardening.IdleTimeoutSeconds = *c.IdleTimeoutSeconds
+	}
+	return hardening, errs
+}
+
+func (c *computeConfig) toProps() (ContainerComputeProps, []string) {
+	var errs []string
+
+	fargate := true
+	if c.Cluster.Fargate != nil {
+		fargate = *c.Cluster.Fargate
+	}
+
+	props := ContainerComputeProps{
+		VpcId: c.VpcId,
+		VpcEndpoints: ContainerComputeVpcEndpointsProps{
+			Enabled: c.VpcEndpoints.Enabled,
+		},
+		Vpc: ContainerComputeVpcProps{
+			Name:        c.Vpc.Name,
+			Cidr:        c.Vpc.Cidr,
+			MaxAzs:      c.Vpc.MaxAzs,
+			NatGateways: c.Vpc.NatGateways,
+		},
+		Cluster: ContainerComputeClusterProps{
+			Name:                             c.Cluster.Name,
+			ContainerInsights:                c.Cluster.ContainerInsights,
+			IsAsgCapacityProviderEnabled:     len(c.AutoScaling) > 0,
+			IsFargateCapacityProviderEnabled: fargate,
+		},
+		LoadBalancer: ContainerComputeLoadBalancerProps{
+			Name:                   c.LoadBalancer.Name,
+			ListenerCertificateArn: c.LoadBalancer.CertificateArn,
+			CertificateArns:        c.LoadBalancer.CertificateArns,
+			HostedZone: ContainerComputeHostedZoneProps{
+				Id:   c.LoadBalancer.HostedZone.Id,
+				Name: c.LoadBalancer.HostedZone.Name,
+			},
+			CertificateDomainNames: c.LoadBalancer.CertificateDomains,
+			Internal:               c.LoadBalancer.Internal,
+			SubnetIds:              c.LoadBalancer.SubnetIds,
+			AllowedCidrs:           c.LoadBalancer.AllowedCidrs,
+			AllowedPrefixLists:     c.LoadBalancer.AllowedPrefixLists,
+			AccessLogs: ContainerComputeAccessLogsProps{
+				Enabled:                   c.LoadBalancer.AccessLogs.Enabled,
+				BucketName:                c.LoadBalancer.AccessLogs.Bucket,
+				Prefix:                    c.LoadBalancer.AccessLogs.Prefix,
+				InfrequentAccessAfterDays: c.LoadBalancer.AccessLogs.InfrequentAccessAfterDays,
+				GlacierAfterDays:          c.LoadBalancer.AccessLogs.GlacierAfterDays,
+				ExpirationDays:            c.LoadBalancer.AccessLogs.ExpirationDays,
+			},
+		},
+		CloudmapNamespace: ContainerComputeCloudmapNamespaceProps{
+			Name:        c.Namespace.Name,
+			Description: c.Namespace.Description,
+		},
+	}
+
+	sessionLogRetention, retentionErrs := parseLogRetention("sessionLogging.logRetention", c.SessionLogging.LogRetention)
+	errs = append(errs, retentionErrs...)
+	props.SessionLogging = SessionLoggingProps{
+		DocumentName:    c.SessionLogging.DocumentName,
+		LogToS3:         c.SessionLogging.S3,
+		LogToCloudWatch: c.SessionLogging.CloudWatch,
+		LogGroupName:    c.SessionLogging.LogGroupName,
+		LogRetention:    sessionLogRetention,
+	}
+
+	var defaultActionErrs []string
+	props.LoadBalancer.DefaultAction, defaultActionErrs = c.LoadBalancer.DefaultAction.toProps("loadBalancer.defaultAction")
+	errs = append(errs, defaultActionErrs...)
+
+	var subnetErrs []string
+	props.LoadBalancer.SubnetType, subnetErrs = parseSubnetType("loadBalancer.subnetType", c.LoadBalancer.SubnetType)
+	errs = append(errs, subnetErrs...)
+	props.VpcEndpoints.SubnetType, subnetErrs = parseSubnetType("vpcEndpoints.subnetType", c.VpcEndpoints.SubnetType)
+	errs = append(errs, subnetErrs...)
+
+	for _, strategy := range c.Cluster.DefaultCapacityProviderStrategy {
+		props.Cluster.DefaultCapacityProviderStrategy = append(props.Cluster.DefaultCapacityProviderStrategy, CapacityProviderStrategyProps{
+			CapacityProvider: strategy.CapacityProvider,
+			Base:             strategy.Base,
+			Weight:           strategy.Weight,
+		})
+	}
+
+	for i, asg := range c.AutoScaling {
+		instanceClass, instanceSize, err := ParseInstanceType(asg.InstanceType)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("autoScalingGroups[%d].instanceType: %v", i, err))
+		}
+		subnetType, subnetErrs := parseSubnetType(fmt.Sprintf("autoScalingGroups[%d].subnetType", i), asg.SubnetType)
+		errs = append(errs, subnetErrs...)
+		var family MachineImageFamily
+		if asg.MachineImage.Family != "" {
+			var ok bool
+			family, ok = machineImageFamilies[strings.ToLower(asg.MachineImage.Family)]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("autoScalingGroups[%d].machineImage.family %q is not one of %s", i, asg.MachineImage.Family, strings.Join(sortedKeys(machineImageFamilies), ", ")))
+			}
+		}
+
+		maxCapacity := 2.0
+		if asg.MaxCapacity != nil {
+			maxCapacity = *asg.MaxCapacity
+		}
+		capacityProvider := asg.CapacityProvider
+		if capacityProvider == "" {
+			capacityProvider = asg.Name + "CapacityProvider"
+		}
+
+		mixedInstances, mixedErrs := asg.MixedInstances.toProps(fmt.Sprintf("autoScalingGroups[%d].mixedInstances", i))
+		errs = append(errs, mixedErrs...)
+		access, accessErrs := asg.Access.toProps(fmt.Sprintf("autoScalingGroups[%d].access", i))
+		errs = append(errs, accessErrs...)
+		role, roleErrs := asg.Role.toProps(fmt.Sprintf("autoScalingGroups[%d].role", i))
+		errs = append(errs, roleErrs...)
+		var volumePlugins []DockerVolumePlugin
+		for _, plugin := range asg.VolumePlugins {
+			volumePlugins = append(volumePlugins, DockerVolumePlugin{
+				Name:     plugin.Name,
+				Alias:    plugin.Alias,
+				Settings: plugin.Settings,
+			})
+		}
+
+		props.AsgCapacityProviders = append(props.AsgCapacityProviders, AutoscalinGroupCapacityProviders{
+			AutoScalingGroup: ContainerComputeAsgProps{
+				Name:            asg.Name,
+				MinCapacity:     asg.MinCapacity,
+				MaxCapacity:     maxCapacity,
+				DesiredCapacity: asg.DesiredCapacity,
+				InstanceClass:   instanceClass,
+				InstanceSize:    instanceSize,
+				SubnetType:      subnetType,
+				MachineImage: ContainerComputeMachineImageProps{
+					Family:       family,
+					AmiId:        asg.MachineImage.AmiId,
+					SsmParameter: asg.MachineImage.SsmParameter,
+				},
+				EcsAgent: EcsAgentConfig{
+					EnableSpotInstanceDraining:  asg.EcsAgent.SpotInstanceDraining,
+					ImagePullBehavior:           EcsImagePullBehavior(asg.EcsAgent.ImagePullBehavior),
+					ContainerStopTimeoutSeconds: asg.EcsAgent.ContainerStopTimeoutSeconds,
+					EnableTaskIamRole:           asg.EcsAgent.TaskIamRole,
+					AllowAwsvpcImds:             asg.EcsAgent.AllowAwsvpcImds,
+				},
+				MixedInstances:     mixedInstances,
+				Access:             access,
+				Role:               role,
+				VolumePlugins:      volumePlugins,
+				AllowImdsv1:        asg.AllowImdsv1,
+				MetadataHopLimit:   asg.MetadataHopLimit,
+				DetailedMonitoring: asg.DetailedMonitoring,
+				RootVolume: ContainerComputeRootVolumeProps{
+					SizeGiB:    asg.RootVolume.SizeGiB,
+					VolumeType: ec2.EbsDeviceVolumeType(strings.ToUpper(asg.RootVolume.VolumeType)),
+					Iops:       asg.RootVolume.Iops,
+					KmsKeyArn:  asg.RootVolume.KmsKeyArn,
+				},
+			},
+			CapacityProvider: ContainerComputeAsgCapacityProviderProps{
+				Name:                               capacityProvider,
+				TargetCapacityPercent:              asg.ManagedScaling.TargetCapacityPercent,
+				MinimumScalingStepSize:             asg.ManagedScaling.MinimumScalingStepSize,
+				MaximumScalingStepSize:             asg.ManagedScaling.MaximumScalingStepSize,
+				InstanceWarmupSeconds:              asg.ManagedScaling.InstanceWarmupSeconds,
+				EnableManagedTerminationProtection: asg.ManagedScaling.ManagedTerminationProtection,
+				BlockContainerInstanceRoleAccess:   asg.BlockInstanceRoleAccess,
+			},
+		})
+	}
+	return props, errs
+}
+
+func (s *serviceConfig) toProps() (ContainerServiceProps, []string) {
+	var errs []string
+
+	desiredCount := 1.0
+	if s.DesiredCount != nil {
+		desiredCount = *s.DesiredCount
+	}
+	cpu := s.Cpu
+	if cpu == 0 {
+		cpu = 256
+	}
+	memory := s.MemoryLimitMiB
+	if memory == 0 {
+		memory = 512
+	}
+	containerPort := s.ContainerPort
+	if containerPort == 0 {
+		containerPort = 80
+	}
+
+	retention, retentionErrs := parseLogRetention("logRetention", s.LogRetention)
+	errs = append(errs, retentionErrs...)
+
+	props := ContainerServiceProps{
+		Name:         s.Name,
+		DesiredCount: desiredCount,
+		TaskDefinition: ContainerServiceTaskDefinitionProps{
+			Family: s.Name,
+		},
+		Container: ContainerServiceContainerProps{
+			Name:            s.Name,
+			Image:           s.Image,
+			Cpu:             cpu,
+			MemoryLimitMiB:  memory,
+			ContainerPort:   containerPort,
+			Environment:     s.Environment,
+			LogGroupName:    "/ecs/" + s.Name,
+			LogRetention:    retention,
+			LogStreamPrefix: s.Name,
+		},
+		TargetGroup: ContainerServiceTargetGroupProps{
+			Name:            s.Name,
+			HealthCheckPath: s.HealthCheckPath,
+		},
+		ListenerRule: ContainerServiceListenerRuleProps{
+			Priority:     s.Priority,
+			HostHeaders:  s.HostHeaders,
+			PathPatterns: s.PathPatterns,
+		},
+		CloudMap: ContainerServiceCloudMapProps{
+			Name: s.Name,
+		},
+		AvailabilityZone:   s.AvailabilityZone,
+		IdleTimeoutSeconds: s.IdleTimeoutSeconds,
+	}
+	if s.CapacityProvider != "" {
+		props.CapacityProviderStrategies = []CapacityProviderStrategyProps{{
+			CapacityProvider: s.CapacityProvider,
+			Weight:           1,
+		}}
+	}
+	for i, volume := range s.Volumes {
+		var scope ecs.Scope
+		if volume.Scope != "" {
+			var ok bool
+			scope, ok = volumeScopes[strings.ToLower(volume.Scope)]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("volumes[%d].scope %q is not one of %s", i, volume.Scope, strings.Join(sortedKeys(volumeScopes), ", ")))
+			}
+		}
+		props.Volumes = append(props.Volumes, DockerVolumeProps{
+			Name:          volume.Name,
+			ContainerPath: volume.ContainerPath,
+			ReadOnly:      volume.ReadOnly,
+			Driver:        volume.Driver,
+			DriverOptions: volume.DriverOptions,
+			Labels:        volume.Labels,
+			Scope:         scope,
+			Autoprovision: volume.Autoprovision,
+		})
+	}
+	return props, errs
+}
+
+// ParseInstanceType maps an EC2 instance type such as "t3.micro" or
+// "m5.2xlarge" onto the ec2 InstanceClass and InstanceSize enums.
+func ParseInstanceType(instanceType string) (ec2.InstanceClass, ec2.InstanceSize, error) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(instanceType)), ".", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("%q is not of the form <class>.<size>, e.g. t3.micro", instanceType)
+	}
+
+	var instanceClass ec2.InstanceClass
+	for _, known := range instanceClasses {
+		if parts[0] == known {
+			instanceClass = ec2.InstanceClass(strings.ToUpper(known))
+			break
+		}
+	}
+	if instanceClass == "" {
+		return "", "", fmt.Errorf("unknown instance class %q", parts[0])
+	}
+
+	instanceSize, ok := instanceSizes[parts[1]]
+	if !ok {
+		return "", "", fmt.Errorf("unknown instance size %q", parts[1])
+	}
+	return instanceClass, instanceSize, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *mixedInstancesConfig) toProps(field string) (ContainerComputeMixedInstancesProps, []string) {
+	if c == nil {
+		return ContainerComputeMixedInstancesProps{}, nil
+	}
+
+	var errs []string
+	props := ContainerComputeMixedInstancesProps{
+		Enabled:              true,
+		OnDemandBaseCapacity: c.OnDemandBaseCapacity,
+		SpotPercentage:       c.SpotPercentage,
+	}
+	for i, name := range c.InstanceTypes {
+		instanceClass, instanceSize, err := ParseInstanceType(name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s.instanceTypes[%d]: %v", field, i, err))
+			continue
+		}
+		props.InstanceTypes = append(props.InstanceTypes, ContainerComputeInstanceType{InstanceClass: instanceClass, InstanceSize: instanceSize})
+	}
+	if c.SpotAllocationStrategy != "" {
+		strategy, ok := spotAllocationStrategies[strings.ToLower(c.SpotAllocationStrategy)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s.spotAllocationStrategy %q is not one of %s", field, c.SpotAllocationStrategy, strings.Join(sortedKeys(spotAllocationStrategies), ", ")))
+		}
+		props.SpotAllocationStrategy = strategy
+	}
+	return props, errs
+}
+
+func (c *accessConfig) toProps(field string) (InstanceAccessProps, []string) {
+	props := InstanceAccessProps{
+		SshKeyName:      c.SshKeyName,
+		SshAllowedCidrs: c.SshAllowedCidrs,
+	}
+	if c.Mode == "" {
+		return props, nil
+	}
+	mode, ok := instanceAccessModes[strings.ToLower(c.Mode)]
+	if !ok {
+		return props, []string{fmt.Sprintf("%s.mode %q is not one of %s", field, c.Mode, strings.Join(sortedKeys(instanceAccessModes), ", "))}
+	}
+	props.Mode = mode
+	return props, nil
+}
+
+func (c *defaultActionConfig) toProps(field string) (ContainerComputeDefaultActionProps, []string) {
+	props := ContainerComputeDefaultActionProps{
+		StatusCode:       c.StatusCode,
+		ContentType:      c.ContentType,
+		MessageBody:      c.MessageBody,
+		RedirectHost:     c.Host,
+		RedirectPath:     c.Path,
+		RedirectPort:     c.Port,
+		RedirectProtocol: strings.ToUpper(c.Protocol),
+		RedirectQuery:    c.Query,
+		Permanent:        c.Permanent,
+		ServiceName:      c.Service,
+		HealthCheckPath:  c.HealthCheckPath,
+	}
+	if c.Type == "" {
+		return props, nil
+	}
+	actionType, ok := defaultActionTypes[strings.ToLower(c.Type)]
+	if !ok {
+		return props, []string{fmt.Sprintf("%s.type %q is not one of %s", field, c.Type, strings.Join(sortedKeys(defaultActionTypes), ", "))}
+	}
+	props.Type = actionType
+	return props, nil
+}
+
+func (c *roleConfig) toProps(field string) (ContainerComputeAsgRoleProps, []string) {
+	var errs []string
+	props := ContainerComputeAsgRoleProps{
+		ManagedPolicyArns:      c.ManagedPolicyArns,
+		PermissionsBoundaryArn: c.PermissionsBoundaryArn,
+	}
+	for _, name := range sortedKeys(c.InlinePolicies) {
+		if _, ok := c.InlinePolicies[name]["Statement"].([]interface{}); !ok {
+			errs = append(errs, fmt.Sprintf("%s.inlinePolicies.%s needs a Statement list", field, name))
+			continue
+		}
+		if props.InlinePolicies == nil {
+			props.InlinePolicies = make(map[string]iam.PolicyDocument)
+		}
+		props.InlinePolicies[name] = iam.PolicyDocument_FromJson(c.InlinePolicies[name])
+	}
+	return props, errs
+}
+
+func parseLogRetention(field, value string) (awslogs.RetentionDays, []string) {
+	if value == "" {
+		return "", nil
+	}
+	retention, ok := logRetentions[strings.ToLower(value)]
+	if !ok {
+		return "", []string{fmt.Sprintf("%s %q is not one of %s", field, value, strings.Join(sortedKeys(logRetentions), ", "))}
+	}
+	return retention, nil
+}
+
+func parseSubnetType(field, value string) (ec2.SubnetType, []string) {
+	if value == "" {
+		return "", nil
+	}
+	subnetType, ok := subnetTypes[strings.ToLower(value)]
+	if !ok {
+		return "", []string{fmt.Sprintf("%s %q is not one of %s", field, value, strings.Join(sortedKeys(subnetTypes), ", "))}
+	}
+	return subnetType, nil
+}
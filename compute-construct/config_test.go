@@ -0,0 +1,247 @@
+package breezeware
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+)
+
+const testConfig = `
+environment:
+  account: "123456789012"
+  region: us-east-1
+compute:
+  vpc:
+    name: Vpc
+  cluster:
+    name: Cluster
+  autoScalingGroups:
+    - name: Asg
+      instanceType: %INSTANCE_TYPE%
+      minCapacity: 0
+      maxCapacity: 2
+  loadBalancer:
+    name: Alb
+    certificateArn: arn:aws:acm:us-east-1:123456789012:certificate/0b1d6f3e-2a7c-4e8b-9f10-3c5d7e9a1b2c
+%LOAD_BALANCER%
+  cloudMapNamespace:
+    name: test
+services:
+  - name: Nginx
+    image: nginx
+`
+
+// writeTestConfig writes testConfig with replacements applied as <env>.yaml
+// and returns its directory.
+func writeTestConfig(t *testing.T, env string, replacements map[string]string) string {
+	t.Helper()
+	content := testConfig
+	for _, placeholder := range []string{"%INSTANCE_TYPE%", "%LOAD_BALANCER%"} {
+		content = strings.ReplaceAll(content, placeholder, replacements[placeholder])
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, env+".yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadEnvironmentConfigs(t *testing.T) {
+	dir := writeTestConfig(t, "staging", map[string]string{"%INSTANCE_TYPE%": "m5.2xlarge"})
+	app := awscdk.NewApp(&awscdk.AppProps{
+		Context: &map[string]interface{}{EnvironmentContextKey: "staging"},
+	})
+
+	configs, err := LoadEnvironmentConfigs(app, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("LoadEnvironmentConfigs() returned %d configs, want 1", len(configs))
+	}
+
+	config := configs[0]
+	if config.Environment.Name != "staging" || config.Environment.Account != "123456789012" {
+		t.Errorf("Environment = %+v, want staging in 123456789012", config.Environment)
+	}
+	asg := config.Compute.AsgCapacityProviders[0]
+	if asg.AutoScalingGroup.InstanceClass != ec2.InstanceClass_M5 || asg.AutoScalingGroup.InstanceSize != ec2.InstanceSize_XLARGE2 {
+		t.Errorf("instance type = %s.%s, want m5.2xlarge", asg.AutoScalingGroup.InstanceClass, asg.AutoScalingGroup.InstanceSize)
+	}
+	if !config.Compute.Cluster.IsAsgCapacityProviderEnabled || !config.Compute.Cluster.IsFargateCapacityProviderEnabled {
+		t.Errorf("Cluster = %+v, want both capacity provider types enabled", config.Compute.Cluster)
+	}
+
+	service := config.Services[0]
+	if service.DesiredCount != 1 || service.Container.MemoryLimitMiB != 512 || service.Container.ContainerPort != 80 {
+		t.Errorf("Services[0] = %+v, want the default count, memory and port", service)
+	}
+}
+
+func TestSelectedEnvironments(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  []string
+	}{
+		{nil, []string{"dev"}},
+		{"prod", []string{"prod"}},
+		{"dev, prod,", []string{"dev", "prod"}},
+		{"all", []string{"dev", "staging", "prod"}},
+	}
+
+	for _, tt := range tests {
+		context := map[string]interface{}{}
+		if tt.value != nil {
+			context[EnvironmentContextKey] = tt.value
+		}
+		app := awscdk.NewApp(&awscdk.AppProps{Context: &context})
+		if got := SelectedEnvironments(app); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SelectedEnvironments(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadEnvironmentConfigErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          string
+		replacements map[string]string
+		want         string
+	}{
+		{
+			name:         "unknown field",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.micro", "%LOAD_BALANCER%": "    internl: true"},
+			want:         "field internl not found",
+		},
+		{
+			name:         "bad instance class",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "q9.micro"},
+			want:         `compute: autoScalingGroups[0].instanceType: unknown instance class "q9"`,
+		},
+		{
+			name:         "retired instance class",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "fpga1.2xlarge"},
+			want:         `compute: autoScalingGroups[0].instanceType: unknown instance class "fpga1"`,
+		},
+		{
+			name:         "bad instance size",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.colossal"},
+			want:         `compute: autoScalingGroups[0].instanceType: unknown instance size "colossal"`,
+		},
+		{
+			name:         "instance type without size",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3"},
+			want:         `"t3" is not of the form <class>.<size>`,
+		},
+		{
+			name:         "unknown subnet type",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.micro", "%LOAD_BALANCER%": "    subnetType: dmz"},
+			want:         `compute: loadBalancer.subnetType "dmz" is not one of isolated, private, public`,
+		},
+		{
+			name:         "unknown default action",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.micro", "%LOAD_BALANCER%": "    defaultAction:\n      type: drop"},
+			want:         `compute: loadBalancer.defaultAction.type "drop" is not one of fixed-response, forward, redirect`,
+		},
+		{
+			name:         "unknown ssl policy",
+			env:          "dev",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.micro", "%LOAD_BALANCER%": "    hardening:\n      sslPolicy: tls10"},
+			want:         `compute.loadBalancer.hardening.sslPolicy "tls10" is not one of`,
+		},
+		{
+			name:         "unknown environment",
+			env:          "qa",
+			replacements: map[string]string{"%INSTANCE_TYPE%": "t3.micro"},
+			want:         `unknown environment "qa"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestConfig(t, tt.env, tt.replacements)
+			_, err := LoadEnvironmentConfig(dir, tt.env)
+			if err == nil {
+				t.Fatalf("LoadEnvironmentConfig() error = nil, want %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadEnvironmentConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironmentConfigOverlays(t *testing.T) {
+	tests := []struct {
+		name              string
+		env               string
+		wantPrefix        string
+		wantRemovalPolicy awscdk.RemovalPolicy
+	}{
+		{name: "dev", env: "dev", wantPrefix: "Dev", wantRemovalPolicy: awscdk.RemovalPolicy_DESTROY},
+		{name: "staging", env: "staging", wantPrefix: "Stg", wantRemovalPolicy: awscdk.RemovalPolicy_DESTROY},
+		{name: "prod", env: "prod", wantPrefix: "Prd", wantRemovalPolicy: awscdk.RemovalPolicy_RETAIN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestConfig(t, tt.env, map[string]string{"%INSTANCE_TYPE%": "t3.micro"})
+			config, err := LoadEnvironmentConfig(dir, tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compute := config.Compute
+			asg := compute.AsgCapacityProviders[0]
+			names := map[string]string{
+				"Cluster.Name":          compute.Cluster.Name,
+				"LoadBalancer.Name":     compute.LoadBalancer.Name,
+				"AutoScalingGroup.Name": asg.AutoScalingGroup.Name,
+				"CapacityProvider.Name": asg.CapacityProvider.Name,
+				"Services[0].Name":      config.Services[0].Name,
+			}
+			for field, name := range names {
+				if !strings.HasPrefix(name, tt.wantPrefix) {
+					t.Errorf("%s = %q, want the %q prefix", field, name, tt.wantPrefix)
+				}
+			}
+			if want := tt.env + ".test"; compute.CloudmapNamespace.Name != want {
+				t.Errorf("CloudmapNamespace.Name = %q, want %q", compute.CloudmapNamespace.Name, want)
+			}
+			if want := "/" + tt.env + "/ecs/Nginx"; config.Services[0].Container.LogGroupName != want {
+				t.Errorf("Container.LogGroupName = %q, want %q", config.Services[0].Container.LogGroupName, want)
+			}
+			if got := config.Services[0].Container.LogRemovalPolicy; got != tt.wantRemovalPolicy {
+				t.Errorf("Container.LogRemovalPolicy = %s, want %s", got, tt.wantRemovalPolicy)
+			}
+			if got := config.Environment.Tags["Environment"]; got != tt.env {
+				t.Errorf("Environment.Tags[Environment] = %q, want %q", got, tt.env)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironmentConfigFileJson(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.json")
+	content := `{"compute": {"cluster": {"name": "Cluster"}}, "services": [{"name": "Api", "imagee": "api"}]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadEnvironmentConfigFile(path)
+	if err == nil || !strings.Contains(err.Error(), `unknown field "imagee"`) {
+		t.Errorf("LoadEnvironmentConfigFile() error = %v, want unknown field", err)
+	}
+}
@@ -0,0 +1,118 @@
+package breezeware
+
+import (
+	"fmt"
+	"strconv"
+
+	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type EcsImagePullBehavior string
+
+const (
+	EcsImagePullDefault      EcsImagePullBehavior = "default"
+	EcsImagePullAlways       EcsImagePullBehavior = "always"
+	EcsImagePullOnce         EcsImagePullBehavior = "once"
+	EcsImagePullPreferCached EcsImagePullBehavior = "prefer-cached"
+)
+
+// EcsAgentConfig holds the ECS agent settings written to /etc/ecs/ecs.config
+// on Amazon Linux hosts or to [settings.ecs] on Bottlerocket. Zero values keep
+// the agent defaults, except that task access to the instance metadata
+// service is blocked unless AllowAwsvpcImds is set.
+type EcsAgentConfig struct {
+	EnableSpotInstanceDraining  bool
+	ImagePullBehavior           EcsImagePullBehavior
+	ContainerStopTimeoutSeconds float64
+	EnableTaskIamRole           bool
+	AllowAwsvpcImds             bool
+}
+
+// UserDataHook returns extra user data lines for a container host. Hooks run
+// in order after the ECS agent settings and volume plugins and must emit lines
+// that suit props.MachineImage.Family, i.e. shell commands or Bottlerocket
+// TOML.
+type UserDataHook func(props *ContainerComputeAsgProps) []string
+
+func addInstanceUserData(asg autoscaling.IAutoScalingGroup, asgCapacityProvider *AutoscalinGroupCapacityProviders) {
+	props := &asgCapacityProvider.AutoScalingGroup
+
+	agent := props.EcsAgent
+	if props.MixedInstances.usesSpot() {
+		agent.EnableSpotInstanceDraining = true
+	}
+	// Blocking the instance role makes the cluster write ECS_AWSVPC_BLOCK_IMDS
+	// itself on Amazon Linux.
+	if asgCapacityProvider.CapacityProvider.BlockContainerInstanceRoleAccess && props.MachineImage.Family != MachineImageBottlerocket {
+		agent.AllowAwsvpcImds = true
+	}
+
+	var lines []string
+	if props.MachineImage.Family == MachineImageBottlerocket {
+		lines = agent.bottlerocketSettings()
+	} else {
+		lines = agent.ecsConfigCommands()
+	}
+
+	lines = append(lines, volumePluginCommands(props)...)
+	for _, hook := range props.UserDataHooks {
+		lines = append(lines, hook(props)...)
+	}
+
+	if len(lines) > 0 {
+		asg.AddUserData(*jsii.Strings(lines...)...)
+	}
+}
+
+func (c *EcsAgentConfig) ecsConfigCommands() []string {
+	var commands []string
+	for _, setting := range c.settings() {
+		commands = append(commands, fmt.Sprintf("echo \"%s=%s\" >> /etc/ecs/ecs.config", setting.agentKey, setting.value))
+	}
+	return commands
+}
+
+// bottlerocketSettings continues the [settings.ecs] table the cluster opens in
+// the host's user data. Task IAM roles are always enabled on Bottlerocket.
+func (c *EcsAgentConfig) bottlerocketSettings() []string {
+	var lines []string
+	for _, setting := range c.settings() {
+		if setting.bottlerocketKey == "" {
+			continue
+		}
+		value := setting.value
+		if !setting.boolean {
+			value = strconv.Quote(value)
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s", setting.bottlerocketKey, value))
+	}
+	return lines
+}
+
+type ecsAgentSetting struct {
+	agentKey        string
+	bottlerocketKey string
+	value           string
+	boolean         bool
+}
+
+func (c *EcsAgentConfig) settings() []ecsAgentSetting {
+	var settings []ecsAgentSetting
+	if !c.AllowAwsvpcImds {
+		settings = append(settings, ecsAgentSetting{"ECS_AWSVPC_BLOCK_IMDS", "awsvpc-block-imds", "true", true})
+	}
+	if c.EnableSpotInstanceDraining {
+		settings = append(settings, ecsAgentSetting{"ECS_ENABLE_SPOT_INSTANCE_DRAINING", "enable-spot-instance-draining", "true", true})
+	}
+	if c.ImagePullBehavior != "" {
+		settings = append(settings, ecsAgentSetting{"ECS_IMAGE_PULL_BEHAVIOR", "image-pull-behavior", string(c.ImagePullBehavior), false})
+	}
+	if c.ContainerStopTimeoutSeconds > 0 {
+		settings = append(settings, ecsAgentSetting{"ECS_CONTAINER_STOP_TIMEOUT", "container-stop-timeout", strconv.FormatFloat(c.ContainerStopTimeoutSeconds, 'f', -1, 64) + "s", false})
+	}
+	if c.EnableTaskIamRole {
+		settings = append(settings, ecsAgentSetting{"ECS_ENABLE_TASK_IAM_ROLE", "", "true", true})
+	}
+	return settings
+}
@@ -0,0 +1,156 @@
+package breezeware
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+)
+
+// userDataRecorder records the lines added to an Auto Scaling group.
+type userDataRecorder struct {
+	autoscaling.IAutoScalingGroup
+	lines []string
+}
+
+func (r *userDataRecorder) AddUserData(commands ...*string) {
+	for _, command := range commands {
+		r.lines = append(r.lines, *command)
+	}
+}
+
+func TestAddInstanceUserData(t *testing.T) {
+	hook := func(props *ContainerComputeAsgProps) []string {
+		if props.MachineImage.Family == MachineImageBottlerocket {
+			return []string{`motd = "hello"`}
+		}
+		return []string{"echo hello > /etc/motd"}
+	}
+
+	tests := []struct {
+		name          string
+		family        MachineImageFamily
+		instanceClass ec2.InstanceClass
+		agent         EcsAgentConfig
+		hooks         []UserDataHook
+		mixed         ContainerComputeMixedInstancesProps
+		blockRole     bool
+		plugins       []DockerVolumePlugin
+		want          []string
+	}{
+		{
+			name:          "al2 defaults",
+			family:        MachineImageEcsAmazonLinux2,
+			instanceClass: ec2.InstanceClass_T3,
+			want:          []string{`echo "ECS_AWSVPC_BLOCK_IMDS=true" >> /etc/ecs/ecs.config`},
+		},
+		{
+			name:          "al2 volume plugins and hook",
+			family:        MachineImageEcsAmazonLinux2,
+			instanceClass: ec2.InstanceClass_T3,
+			plugins: []DockerVolumePlugin{
+				{Name: RexrayEbsVolumePlugin},
+				{Name: "vieux/sshfs", Alias: "sshfs", Settings: map[string]string{"DEBUG": "1", "SSH_ARGS": "-o 'Port 2222'"}},
+			},
+			hooks: []UserDataHook{hook},
+			want: []string{
+				`echo "ECS_AWSVPC_BLOCK_IMDS=true" >> /etc/ecs/ecs.config`,
+				"docker plugin install --grant-all-permissions rexray/ebs EBS_REGION='" + *awscdk.Aws_REGION() + "' REXRAY_PREEMPT='true'",
+				`docker plugin install --grant-all-permissions --alias sshfs vieux/sshfs DEBUG='1' SSH_ARGS='-o '\''Port 2222'\'''`,
+				"echo hello > /etc/motd",
+			},
+		},
+		{
+			name:          "al2023 arm64 settings and hook",
+			family:        MachineImageEcsAmazonLinux2023,
+			instanceClass: ec2.InstanceClass_T4G,
+			agent: EcsAgentConfig{
+				EnableSpotInstanceDraining:  true,
+				ImagePullBehavior:           EcsImagePullPreferCached,
+				ContainerStopTimeoutSeconds: 60,
+				EnableTaskIamRole:           true,
+			},
+			hooks: []UserDataHook{hook},
+			want: []string{
+				`echo "ECS_AWSVPC_BLOCK_IMDS=true" >> /etc/ecs/ecs.config`,
+				`echo "ECS_ENABLE_SPOT_INSTANCE_DRAINING=true" >> /etc/ecs/ecs.config`,
+				`echo "ECS_IMAGE_PULL_BEHAVIOR=prefer-cached" >> /etc/ecs/ecs.config`,
+				`echo "ECS_CONTAINER_STOP_TIMEOUT=60s" >> /etc/ecs/ecs.config`,
+				`echo "ECS_ENABLE_TASK_IAM_ROLE=true" >> /etc/ecs/ecs.config`,
+				"echo hello > /etc/motd",
+			},
+		},
+		{
+			name:          "al2023 spot",
+			family:        MachineImageEcsAmazonLinux2023,
+			instanceClass: ec2.InstanceClass_T4G,
+			mixed:         ContainerComputeMixedInstancesProps{Enabled: true, SpotPercentage: 100},
+			want: []string{
+				`echo "ECS_AWSVPC_BLOCK_IMDS=true" >> /etc/ecs/ecs.config`,
+				`echo "ECS_ENABLE_SPOT_INSTANCE_DRAINING=true" >> /etc/ecs/ecs.config`,
+			},
+		},
+		{
+			name:          "bottlerocket spot",
+			family:        MachineImageBottlerocket,
+			instanceClass: ec2.InstanceClass_T3,
+			mixed:         ContainerComputeMixedInstancesProps{Enabled: true, SpotPercentage: 50},
+			want:          []string{"awsvpc-block-imds = true", "enable-spot-instance-draining = true"},
+		},
+		{
+			name:          "al2 with metadata access",
+			family:        MachineImageEcsAmazonLinux2,
+			instanceClass: ec2.InstanceClass_T4G,
+			agent:         EcsAgentConfig{AllowAwsvpcImds: true},
+		},
+		{
+			name:          "al2 with blocked instance role",
+			family:        MachineImageEcsAmazonLinux2,
+			instanceClass: ec2.InstanceClass_T4G,
+			blockRole:     true,
+		},
+		{
+			name:          "bottlerocket with blocked instance role",
+			family:        MachineImageBottlerocket,
+			instanceClass: ec2.InstanceClass_T3,
+			blockRole:     true,
+			want:          []string{"awsvpc-block-imds = true"},
+		},
+		{
+			name:          "bottlerocket settings and hook",
+			family:        MachineImageBottlerocket,
+			instanceClass: ec2.InstanceClass_T3,
+			agent:         EcsAgentConfig{ImagePullBehavior: EcsImagePullOnce, ContainerStopTimeoutSeconds: 30, EnableTaskIamRole: true},
+			hooks:         []UserDataHook{hook},
+			want: []string{
+				"awsvpc-block-imds = true",
+				`image-pull-behavior = "once"`,
+				`container-stop-timeout = "30s"`,
+				`motd = "hello"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asg := &userDataRecorder{}
+			addInstanceUserData(asg, &AutoscalinGroupCapacityProviders{
+				AutoScalingGroup: ContainerComputeAsgProps{
+					InstanceClass:  tt.instanceClass,
+					InstanceSize:   ec2.InstanceSize_MICRO,
+					MachineImage:   ContainerComputeMachineImageProps{Family: tt.family},
+					EcsAgent:       tt.agent,
+					UserDataHooks:  tt.hooks,
+					MixedInstances: tt.mixed,
+					VolumePlugins:  tt.plugins,
+				},
+				CapacityProvider: ContainerComputeAsgCapacityProviderProps{BlockContainerInstanceRoleAccess: tt.blockRole},
+			})
+			if !reflect.DeepEqual(asg.lines, tt.want) {
+				t.Errorf("user data = %q, want %q", asg.lines, tt.want)
+			}
+		})
+	}
+}
@@ -1,45 +1,99 @@
 package breezeware
 
 import (
+	"strconv"
+
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	acm "github.com/aws/aws-cdk-go/awscdk/v2/awscertificatemanager"
 	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
 	ecs "github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
 	elbv2 "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
 	iam "github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	kms "github.com/aws/aws-cdk-go/awscdk/v2/awskms"
+	route53 "github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	servicediscovery "github.com/aws/aws-cdk-go/awscdk/v2/awsservicediscovery"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
 )
 
-var vpc ec2.IVpc
-
 type ContainerCompute interface {
 	constructs.Construct
+	Vpc() ec2.IVpc
 	Cluster() ecs.ICluster
 	LoadBalancer() elbv2.IApplicationLoadBalancer
 	CloudMapNamespace() servicediscovery.IPrivateDnsNamespace
 	HttpsListener() elbv2.IApplicationListener
+	LoadBalancerSecurityGroup() ec2.ISecurityGroup
+	CapacityProviders() map[string]ecs.AsgCapacityProvider
+	AutoScalingGroups() map[string]autoscaling.IAutoScalingGroup
+	AutoScalingGroupSecurityGroups() map[string]ec2.ISecurityGroup
+	AutoScalingGroupRoles() map[string]iam.IRole
+	CapacityProviderVolumeDrivers() map[string][]string
+	DefaultCapacityProviderStrategy() []CapacityProviderStrategyProps
+	DefaultTargetGroups() map[string]elbv2.ApplicationTargetGroup
+	AttachService(name string, target elbv2.IApplicationLoadBalancerTarget) elbv2.IApplicationTargetGroup
+	HostedZone() route53.IHostedZone
+	AliasRecordNames() []string
+	AccessLogBucket() awss3.IBucket
+	ListenerRulePriority(key string, priority float64) float64
+	IdleTimeoutSeconds() float64
+	SessionLogging() SessionLogging
 }
 
 type containerCompute struct {
 	constructs.Construct
+	vpc               ec2.IVpc
 	cluster           ecs.ICluster
-	loadbalancer      elbv2.IApplicationLoadBalancer
+	loadbalancer      elbv2.ApplicationLoadBalancer
+	idleTimeout       float64
 	cloudmapNamespace servicediscovery.IPrivateDnsNamespace
 	httpsListener     elbv2.IApplicationListener
+	lbSecurityGroup   ec2.ISecurityGroup
+	capacityProviders map[string]ecs.AsgCapacityProvider
+	asgs              map[string]autoscaling.IAutoScalingGroup
+	asgSecurityGroups map[string]ec2.ISecurityGroup
+	asgRoles          map[string]iam.IRole
+	volumeDrivers     map[string][]string
+	defaultStrategy   []CapacityProviderStrategyProps
+	defaultTgs        map[string]elbv2.ApplicationTargetGroup
+	services          map[string]bool
+	hostedZone        route53.IHostedZone
+	aliasRecordNames  []string
+	accessLogBucket   awss3.IBucket
+	rulePriorities    *listenerRulePriorities
+	sessionLogging    SessionLogging
 }
 
 type VpcProps struct {
 	VpcId string
 }
 
+type ContainerComputeVpcProps struct {
+	Name        string
+	Cidr        string
+	MaxAzs      float64
+	NatGateways float64
+}
+
+const (
+	FargateCapacityProvider     = "FARGATE"
+	FargateSpotCapacityProvider = "FARGATE_SPOT"
+)
+
+type CapacityProviderStrategyProps struct {
+	CapacityProvider string
+	Base             float64
+	Weight           float64
+}
+
 type ContainerComputeClusterProps struct {
 	Name                             string
 	ContainerInsights                bool
 	IsAsgCapacityProviderEnabled     bool
 	IsFargateCapacityProviderEnabled bool
-	vpc                              ec2.IVpc
+	DefaultCapacityProviderStrategy  []CapacityProviderStrategyProps
 }
 
 type ContainerComputeAsgProps struct {
@@ -47,32 +101,185 @@ type ContainerComputeAsgProps struct {
 	MinCapacity     float64
 	MaxCapacity     float64
 	DesiredCapacity float64
-	SshKeyName      string
+	Access          InstanceAccessProps
+	Role            ContainerComputeAsgRoleProps
 	InstanceClass   ec2.InstanceClass
 	InstanceSize    ec2.InstanceSize
-	vpc             ec2.IVpc
+	SubnetType      ec2.SubnetType
+	MachineImage    ContainerComputeMachineImageProps
+	EcsAgent        EcsAgentConfig
+	UserDataHooks   []UserDataHook
+	VolumePlugins   []DockerVolumePlugin
+	MixedInstances  ContainerComputeMixedInstancesProps
+	// Instances require IMDSv2 with a hop limit of 2 unless AllowImdsv1
+	// or MetadataHopLimit say otherwise.
+	AllowImdsv1        bool
+	MetadataHopLimit   float64
+	DetailedMonitoring bool
+	RootVolume         ContainerComputeRootVolumeProps
+}
+
+// ContainerComputeAsgRoleProps adds to the instance role of a group. The role
+// only gets EBS volume permissions when the hosts run the rexray/ebs plugin.
+type ContainerComputeAsgRoleProps struct {
+	ManagedPolicyArns      []string
+	InlinePolicies         map[string]iam.PolicyDocument
+	PermissionsBoundaryArn string
+}
+
+// ContainerComputeRootVolumeProps describes the encrypted EBS volume that
+// holds container images. It defaults to 30 GiB of gp3 with the AWS managed
+// key.
+type ContainerComputeRootVolumeProps struct {
+	SizeGiB    float64
+	VolumeType ec2.EbsDeviceVolumeType
+	Iops       float64
+	KmsKeyArn  string
+}
+
+type ContainerComputeInstanceType struct {
+	InstanceClass ec2.InstanceClass
+	InstanceSize  ec2.InstanceSize
+}
+
+// ContainerComputeMixedInstancesProps launches the group from a launch template
+// with a mixed instances policy. InstanceTypes are tried after the group's own
+// InstanceClass and InstanceSize. OnDemandBaseCapacity instances are always
+// on-demand and SpotPercentage of the rest run on Spot.
+type ContainerComputeMixedInstancesProps struct {
+	Enabled                bool
+	InstanceTypes          []ContainerComputeInstanceType
+	OnDemandBaseCapacity   float64
+	SpotPercentage         float64
+	SpotAllocationStrategy autoscaling.SpotAllocationStrategy
 }
 
+func (p *ContainerComputeMixedInstancesProps) usesSpot() bool {
+	return p.Enabled && p.SpotPercentage > 0
+}
+
+type MachineImageFamily string
+
+const (
+	MachineImageEcsAmazonLinux2    MachineImageFamily = "ECS_AL2"
+	MachineImageEcsAmazonLinux2023 MachineImageFamily = "ECS_AL2023"
+	MachineImageBottlerocket       MachineImageFamily = "BOTTLEROCKET"
+	MachineImageCustom             MachineImageFamily = "CUSTOM"
+)
+
+// ContainerComputeMachineImageProps picks the container host AMI. Family
+// defaults to the ECS-optimized Amazon Linux 2 image. A custom image is given
+// by AmiId or SsmParameter and must be an ECS-ready Amazon Linux derivative.
+type ContainerComputeMachineImageProps struct {
+	Family       MachineImageFamily
+	AmiId        string
+	SsmParameter string
+}
+
+// ContainerComputeAsgCapacityProviderProps tunes ECS managed scaling. Managed
+// termination protection also protects new instances from ASG scale-in so
+// only instances without tasks are removed.
 type ContainerComputeAsgCapacityProviderProps struct {
-	Name string
+	Name                               string
+	TargetCapacityPercent              float64
+	MinimumScalingStepSize             float64
+	MaximumScalingStepSize             float64
+	InstanceWarmupSeconds              float64
+	EnableManagedTerminationProtection bool
+	BlockContainerInstanceRoleAccess   bool
 }
 
 type ContainerComputeLoadBalancerProps struct {
 	Name                   string
 	ListenerCertificateArn string
-	vpc                    ec2.IVpc
+	// CertificateArns are served next to ListenerCertificateArn through SNI.
+	CertificateArns []string
+	HostedZone      ContainerComputeHostedZoneProps
+	// CertificateDomainNames each get an ACM certificate that is validated
+	// through DNS records in HostedZone.
+	CertificateDomainNames []string
+	Internal               bool
+	SubnetType             ec2.SubnetType
+	SubnetIds              []string
+	AllowedCidrs           []string
+	AllowedPrefixLists     []string
+	DefaultAction          ContainerComputeDefaultActionProps
+	Hardening              ContainerComputeLoadBalancerHardeningProps
+	AccessLogs             ContainerComputeAccessLogsProps
+}
+
+// ContainerComputeLoadBalancerHardeningProps tunes the load balancer and its
+// HTTPS listener. The zero value keeps the AWS defaults with a 120 second idle
+// timeout. Environments start from DefaultLoadBalancerHardening or
+// ProdLoadBalancerHardening.
+type ContainerComputeLoadBalancerHardeningProps struct {
+	SslPolicy               elbv2.SslPolicy
+	DeletionProtection      bool
+	DisableHttp2            bool
+	DropInvalidHeaderFields bool
+	DesyncMitigationMode    elbv2.DesyncMitigationMode
+	// IdleTimeoutSeconds applies to every service behind the load balancer,
+	// so it must cover the longest ContainerServiceProps.IdleTimeoutSeconds.
+	IdleTimeoutSeconds float64
+}
+
+const defaultIdleTimeoutSeconds = 120
+
+func (p *ContainerComputeLoadBalancerHardeningProps) idleTimeout() float64 {
+	if p.IdleTimeoutSeconds == 0 {
+		return defaultIdleTimeoutSeconds
+	}
+	return p.IdleTimeoutSeconds
+}
+
+// ContainerComputeHostedZoneProps names a Route 53 public hosted zone. Without
+// an Id the zone is looked up by Name.
+type ContainerComputeHostedZoneProps struct {
+	Id   string
+	Name string
+}
+
+func (p *ContainerComputeHostedZoneProps) enabled() bool {
+	return p.Name != ""
+}
+
+type DefaultActionType string
+
+const (
+	DefaultActionFixedResponse DefaultActionType = "FIXED_RESPONSE"
+	DefaultActionRedirect      DefaultActionType = "REDIRECT"
+	DefaultActionForward       DefaultActionType = "FORWARD"
+)
+
+// ContainerComputeDefaultActionProps decides what the HTTPS listener does with
+// requests no service rule matches. It defaults to a plain 404 response.
+// Redirect fields that are left empty keep the request's own value. Forward
+// creates the target group that the service named ServiceName registers into,
+// with a health check on HealthCheckPath that expects HealthyHttpCodes.
+type ContainerComputeDefaultActionProps struct {
+	Type             DefaultActionType
+	StatusCode       float64
+	ContentType      string
+	MessageBody      string
+	RedirectHost     string
+	RedirectPath     string
+	RedirectPort     string
+	RedirectProtocol string
+	RedirectQuery    string
+	Permanent        bool
+	ServiceName      string
+	HealthCheckPath  string
+	HealthyHttpCodes string
 }
 
 type ContainerComputeCloudmapNamespaceProps struct {
 	Name        string
 	Description string
-	vpc         ec2.IVpc
 }
 
 type securityGroupProps struct {
 	Name        string
 	Description string
-	vpc         ec2.IVpc
 }
 
 type AutoscalinGroupCapacityProviders struct {
@@ -80,41 +287,172 @@ type AutoscalinGroupCapacityProviders struct {
 	CapacityProvider ContainerComputeAsgCapacityProviderProps
 }
 
+type ContainerComputeVpcEndpointsProps struct {
+	Enabled    bool
+	SubnetType ec2.SubnetType
+}
+
 type ContainerComputeProps struct {
 	VpcId                *string
+	Vpc                  ContainerComputeVpcProps
+	VpcEndpoints         ContainerComputeVpcEndpointsProps
 	Cluster              ContainerComputeClusterProps
 	AsgCapacityProviders []AutoscalinGroupCapacityProviders
 	LoadBalancer         ContainerComputeLoadBalancerProps
 	CloudmapNamespace    ContainerComputeCloudmapNamespaceProps
+	SessionLogging       SessionLoggingProps
 }
 
 func NewContainerCompute(scope constructs.Construct, id *string, props *ContainerComputeProps) ContainerCompute {
 
 	this := constructs.NewConstruct(scope, id)
 
-	vpc = LookupVpc(scope, jsii.String("LookUpVpc"), &VpcProps{VpcId: *props.VpcId})
+	this.Node().AddValidation(&propsValidation{validate: props.validate})
+
+	var vpc ec2.IVpc
+	if props.VpcId != nil {
+		vpc = LookupVpc(this, jsii.String("LookUpVpc"), &VpcProps{VpcId: *props.VpcId})
+	} else {
+		vpc = createVpc(this, jsii.String("Vpc"), &props.Vpc)
+	}
+
+	if props.VpcEndpoints.Enabled {
+		createVpcEndpoints(this, jsii.String("VpcEndpoints"), &props.VpcEndpoints, vpc)
+	}
 
-	cluster := createCluster(this, jsii.String("EcsCluster"), &props.Cluster)
+	cluster := createCluster(this, jsii.String("EcsCluster"), &props.Cluster, vpc)
+
+	var sessionLogging SessionLogging
+	if props.SessionLogging.enabled() {
+		sessionLoggingProps := props.SessionLogging
+		if sessionLoggingProps.DocumentName == "" {
+			sessionLoggingProps.DocumentName = props.Cluster.Name + "SessionManagerRunShell"
+		}
+		sessionLogging = NewSessionLogging(this, jsii.String("SessionLogging"), &sessionLoggingProps)
+	}
+
+	capacityProviders := make(map[string]ecs.AsgCapacityProvider)
+	asgs := make(map[string]autoscaling.IAutoScalingGroup)
+	asgSecurityGroups := make(map[string]ec2.ISecurityGroup)
+	asgRoles := make(map[string]iam.IRole)
+	asgVolumeDrivers := make(map[string][]string)
 
 	if props.Cluster.IsAsgCapacityProviderEnabled {
 		for _, asgCapacityProvider := range props.AsgCapacityProviders {
+			asgProps := &asgCapacityProvider.AutoScalingGroup
+
+			asgSecurityGroup := createAsgSecurityGroup(this, jsii.String(asgProps.Name+"SecurityGroup"), &securityGroupProps{
+				Name:        asgProps.Name + "SecurityGroup",
+				Description: "SecurityGroup for " + asgProps.Name,
+			},
+				vpc,
+			)
 
-			autoScalingGroup := createAutoScalingGroup(this, jsii.String(asgCapacityProvider.AutoScalingGroup.Name+"AutoscalingGroup"), &asgCapacityProvider.AutoScalingGroup, *cluster.ClusterName())
+			asgRole := createAsgRole(this, jsii.String("IamRole"+asgProps.Name), asgProps, createAsgPolicyDocuments(asgProps))
 
-			capacityProvider := createCapacityProvider(this, jsii.String(asgCapacityProvider.CapacityProvider.Name+"AsgCapacityProvider"), &asgCapacityProvider.CapacityProvider, autoScalingGroup)
+			// The cluster attaches AmazonSSMManagedInstanceCore to Bottlerocket
+			// roles when it adds the capacity provider.
+			configureInstanceAccess(asgRole, asgSecurityGroup, &asgProps.Access, asgProps.MachineImage.Family == MachineImageBottlerocket)
+			if sessionLogging != nil && asgProps.Access.sessionManagerEnabled() {
+				sessionLogging.GrantWrite(asgRole)
+			}
+
+			autoScalingGroup := createAutoScalingGroup(this, jsii.String(asgProps.Name+"AutoscalingGroup"), asgProps, vpc, asgSecurityGroup, asgRole)
+
+			capacityProvider := createCapacityProvider(this, jsii.String(asgCapacityProvider.CapacityProvider.Name+"AsgCapacityProvider"), &asgCapacityProvider.CapacityProvider, autoScalingGroup, machineImageType(&asgProps.MachineImage))
 
 			cluster.AddAsgCapacityProvider(capacityProvider, &ecs.AddAutoScalingGroupCapacityOptions{})
+
+			// The cluster writes its own ECS_CLUSTER or [settings.ecs] lines
+			// first, so host settings go in after the capacity provider.
+			addInstanceUserData(autoScalingGroup, &asgCapacityProvider)
+
+			capacityProviders[asgCapacityProvider.CapacityProvider.Name] = capacityProvider
+			asgs[asgProps.Name] = autoScalingGroup
+			asgSecurityGroups[asgProps.Name] = asgSecurityGroup
+			asgRoles[asgProps.Name] = asgRole
+			asgVolumeDrivers[asgCapacityProvider.CapacityProvider.Name] = volumeDrivers(asgProps)
 		}
 	}
-	loadBalancer := createLoadBalancer(this, jsii.String("LoadBalanerSetup"), &props.LoadBalancer)
 
-	httpsListener := createHttpsListener(this, jsii.String("HttpsListener"), &props.LoadBalancer, loadBalancer)
+	if len(props.Cluster.DefaultCapacityProviderStrategy) > 0 {
+		addDefaultCapacityProviderStrategy(cluster, props.Cluster.DefaultCapacityProviderStrategy, capacityProviders)
+	}
+
+	lbSecurityGroup := createLbSecurityGroup(this, jsii.String(props.LoadBalancer.Name+"SecurityGroup"), &securityGroupProps{
+		Name:        props.LoadBalancer.Name + "SecurityGroup",
+		Description: "Security group for " + props.LoadBalancer.Name,
+	},
+		vpc,
+		lbIngressPeers(&props.LoadBalancer, vpc),
+	)
+
+	loadBalancer := createLoadBalancer(this, jsii.String("LoadBalanerSetup"), &props.LoadBalancer, vpc, lbSecurityGroup)
+
+	var accessLogBucket awss3.IBucket
+	if props.LoadBalancer.AccessLogs.Enabled {
+		accessLogBucket = createAccessLogBucket(this, jsii.String("AccessLogBucket"), &props.LoadBalancer.AccessLogs)
+		configureAccessLogs(loadBalancer, accessLogBucket, &props.LoadBalancer.AccessLogs)
+	}
+
+	var hostedZone route53.IHostedZone
+	if props.LoadBalancer.HostedZone.enabled() {
+		hostedZone = createHostedZone(this, jsii.String("HostedZone"), &props.LoadBalancer.HostedZone)
+	}
+	certificates := createListenerCertificates(this, jsii.String("Certificate"), &props.LoadBalancer, hostedZone)
+
+	defaultTargetGroups := make(map[string]elbv2.ApplicationTargetGroup)
+	if props.LoadBalancer.DefaultAction.Type == DefaultActionForward {
+		defaultTargetGroups[props.LoadBalancer.DefaultAction.ServiceName] = createDefaultTargetGroup(this, jsii.String("DefaultTargetGroup"), &props.LoadBalancer.DefaultAction, vpc)
+	}
+
+	rulePriorities := newListenerRulePriorities()
+	this.Node().AddValidation(&propsValidation{validate: rulePriorities.validate})
+	services := make(map[string]bool)
+	this.Node().AddValidation(&propsValidation{validate: func() []string {
+		return withPrefix("LoadBalancer.DefaultAction", props.LoadBalancer.DefaultAction.validateForwardTarget(services))
+	}})
+
+	httpsListener := createHttpsListener(this, jsii.String("HttpsListener"), &props.LoadBalancer, loadBalancer, certificates, defaultTargetGroups)
 
 	createHttpListener(this, jsii.String("HttpListener"), loadBalancer)
 
-	cloudmapNamespace := createCloudMapNamespace(this, jsii.String("CloudMapNamespace"), &props.CloudmapNamespace)
+	// The certificate domains are served by the listener itself, and reach its
+	// default action unless a service rule matches.
+	var aliasRecordNames []string
+	if hostedZone != nil {
+		aliasRecordNames = createAliasRecords(this, jsii.String("AliasRecord"), props.LoadBalancer.CertificateDomainNames, hostedZone, loadBalancer, nil)
+	}
+
+	cloudmapNamespace := createCloudMapNamespace(this, jsii.String("CloudMapNamespace"), &props.CloudmapNamespace, vpc)
+
+	return &containerCompute{
+		Construct:         this,
+		vpc:               vpc,
+		cluster:           cluster,
+		loadbalancer:      loadBalancer,
+		idleTimeout:       props.LoadBalancer.Hardening.idleTimeout(),
+		cloudmapNamespace: cloudmapNamespace,
+		httpsListener:     httpsListener,
+		lbSecurityGroup:   lbSecurityGroup,
+		capacityProviders: capacityProviders,
+		asgs:              asgs,
+		asgSecurityGroups: asgSecurityGroups,
+		asgRoles:          asgRoles,
+		volumeDrivers:     asgVolumeDrivers,
+		defaultStrategy:   props.Cluster.DefaultCapacityProviderStrategy,
+		defaultTgs:        defaultTargetGroups,
+		services:          services,
+		hostedZone:        hostedZone,
+		aliasRecordNames:  aliasRecordNames,
+		accessLogBucket:   accessLogBucket,
+		rulePriorities:    rulePriorities,
+		sessionLogging:    sessionLogging,
+	}
+}
 
-	return &containerCompute{this, cluster, loadBalancer, cloudmapNamespace, httpsListener}
+func (v *containerCompute) Vpc() ec2.IVpc {
+	return v.vpc
 }
 
 func (c *containerCompute) Cluster() ecs.ICluster {
@@ -133,6 +471,97 @@ func (hl *containerCompute) HttpsListener() elbv2.IApplicationListener {
 	return hl.httpsListener
 }
 
+func (sg *containerCompute) LoadBalancerSecurityGroup() ec2.ISecurityGroup {
+	return sg.lbSecurityGroup
+}
+
+// CapacityProviders returns the ASG capacity providers keyed by capacity
+// provider name.
+func (cp *containerCompute) CapacityProviders() map[string]ecs.AsgCapacityProvider {
+	return cp.capacityProviders
+}
+
+// AutoScalingGroups returns the container instance ASGs keyed by ASG name, as
+// do AutoScalingGroupSecurityGroups and AutoScalingGroupRoles.
+func (asg *containerCompute) AutoScalingGroups() map[string]autoscaling.IAutoScalingGroup {
+	return asg.asgs
+}
+
+func (sg *containerCompute) AutoScalingGroupSecurityGroups() map[string]ec2.ISecurityGroup {
+	return sg.asgSecurityGroups
+}
+
+func (r *containerCompute) AutoScalingGroupRoles() map[string]iam.IRole {
+	return r.asgRoles
+}
+
+// ListenerRulePriority returns the HTTPS listener rule priority for key, a
+// stable name such as the service name. A priority above zero is kept as it
+// is, otherwise one is derived from key alone. Priorities that clash fail
+// validation.
+func (p *containerCompute) ListenerRulePriority(key string, priority float64) float64 {
+	return p.rulePriorities.claim(key, priority)
+}
+
+// AccessLogBucket returns the bucket that receives the load balancer's access
+// logs, or nil when access logging is off.
+func (b *containerCompute) AccessLogBucket() awss3.IBucket {
+	return b.accessLogBucket
+}
+
+// IdleTimeoutSeconds returns the load balancer's idle timeout, which services
+// that need longer lived connections cannot exceed.
+func (lb *containerCompute) IdleTimeoutSeconds() float64 {
+	return lb.idleTimeout
+}
+
+// HostedZone returns the load balancer's hosted zone, or nil when none is
+// configured.
+func (z *containerCompute) HostedZone() route53.IHostedZone {
+	return z.hostedZone
+}
+
+// AliasRecordNames lists the hosts the compute created alias records for.
+func (z *containerCompute) AliasRecordNames() []string {
+	return z.aliasRecordNames
+}
+
+// DefaultTargetGroups holds the HTTPS listener's default target group, keyed
+// by the name of the service that registers into it.
+func (d *containerCompute) DefaultTargetGroups() map[string]elbv2.ApplicationTargetGroup {
+	return d.defaultTgs
+}
+
+// AttachService records the service named name as running on the compute.
+// When the HTTPS listener forwards to it by default, target is registered
+// into the default target group and that group is returned. Other services
+// get nil and bring their own.
+func (d *containerCompute) AttachService(name string, target elbv2.IApplicationLoadBalancerTarget) elbv2.IApplicationTargetGroup {
+	d.services[name] = true
+	targetGroup, ok := d.defaultTgs[name]
+	if !ok {
+		return nil
+	}
+	targetGroup.AddTarget(target)
+	return targetGroup
+}
+
+// CapacityProviderVolumeDrivers lists the Docker volume drivers available on
+// each ASG capacity provider, keyed by capacity provider name.
+func (d *containerCompute) CapacityProviderVolumeDrivers() map[string][]string {
+	return d.volumeDrivers
+}
+
+// DefaultCapacityProviderStrategy is the cluster's strategy for services that
+// do not set their own.
+func (d *containerCompute) DefaultCapacityProviderStrategy() []CapacityProviderStrategyProps {
+	return d.defaultStrategy
+}
+
+func (l *containerCompute) SessionLogging() SessionLogging {
+	return l.sessionLogging
+}
+
 func LookupVpc(scope constructs.Construct, id *string, props *VpcProps) ec2.IVpc {
 	vpc := ec2.Vpc_FromLookup(scope, id, &ec2.VpcLookupOptions{
 		VpcId: jsii.String(props.VpcId),
@@ -140,7 +569,80 @@ func LookupVpc(scope constructs.Construct, id *string, props *VpcProps) ec2.IVpc
 	return vpc
 }
 
-func createCluster(scope constructs.Construct, id *string, props *ContainerComputeClusterProps) ecs.Cluster {
+func createVpc(scope constructs.Construct, id *string, props *ContainerComputeVpcProps) ec2.IVpc {
+	cidr := props.Cidr
+	if cidr == "" {
+		cidr = "10.0.0.0/16"
+	}
+	maxAzs := props.MaxAzs
+	if maxAzs == 0 {
+		maxAzs = 2
+	}
+	natGateways := props.NatGateways
+	if natGateways == 0 {
+		natGateways = 1
+	}
+
+	vpc := ec2.NewVpc(scope, id, &ec2.VpcProps{
+		VpcName:            jsii.String(props.Name),
+		IpAddresses:        ec2.IpAddresses_Cidr(jsii.String(cidr)),
+		MaxAzs:             jsii.Number(maxAzs),
+		NatGateways:        jsii.Number(natGateways),
+		EnableDnsHostnames: jsii.Bool(true),
+		EnableDnsSupport:   jsii.Bool(true),
+		SubnetConfiguration: &[]*ec2.SubnetConfiguration{
+			{
+				Name:       jsii.String("Public"),
+				SubnetType: ec2.SubnetType_PUBLIC,
+				CidrMask:   jsii.Number(24),
+			},
+			{
+				Name:       jsii.String("Private"),
+				SubnetType: ec2.SubnetType_PRIVATE_WITH_EGRESS,
+				CidrMask:   jsii.Number(20),
+			},
+			{
+				Name:       jsii.String("Isolated"),
+				SubnetType: ec2.SubnetType_PRIVATE_ISOLATED,
+				CidrMask:   jsii.Number(24),
+			},
+		},
+	})
+	return vpc
+}
+
+// createVpcEndpoints adds the endpoints container instances need to join the
+// cluster, pull from ECR and ship logs when their subnets have no NAT.
+func createVpcEndpoints(scope constructs.Construct, id *string, props *ContainerComputeVpcEndpointsProps, vpc ec2.IVpc) {
+	var subnets *ec2.SubnetSelection
+	if props.SubnetType != "" {
+		subnets = &ec2.SubnetSelection{SubnetType: props.SubnetType}
+	}
+
+	interfaceServices := map[string]ec2.InterfaceVpcEndpointAwsService{
+		"Ecs":          ec2.InterfaceVpcEndpointAwsService_ECS(),
+		"EcsAgent":     ec2.InterfaceVpcEndpointAwsService_ECS_AGENT(),
+		"EcsTelemetry": ec2.InterfaceVpcEndpointAwsService_ECS_TELEMETRY(),
+		"EcrApi":       ec2.InterfaceVpcEndpointAwsService_ECR(),
+		"EcrDocker":    ec2.InterfaceVpcEndpointAwsService_ECR_DOCKER(),
+		"Logs":         ec2.InterfaceVpcEndpointAwsService_CLOUDWATCH_LOGS(),
+	}
+	for _, name := range sortedKeys(interfaceServices) {
+		ec2.NewInterfaceVpcEndpoint(scope, jsii.String(*id+name), &ec2.InterfaceVpcEndpointProps{
+			Vpc:               vpc,
+			Service:           interfaceServices[name],
+			PrivateDnsEnabled: jsii.Bool(true),
+			Subnets:           subnets,
+		})
+	}
+
+	ec2.NewGatewayVpcEndpoint(scope, jsii.String(*id+"S3"), &ec2.GatewayVpcEndpointProps{
+		Vpc:     vpc,
+		Service: ec2.GatewayVpcEndpointAwsService_S3(),
+	})
+}
+
+func createCluster(scope constructs.Construct, id *string, props *ContainerComputeClusterProps, vpc ec2.IVpc) ecs.Cluster {
 	if props.IsFargateCapacityProviderEnabled {
 		cluster := ecs.NewCluster(scope, id, &ecs.ClusterProps{
 			ClusterName:                    jsii.String(props.Name),
@@ -160,7 +662,81 @@ func createCluster(scope constructs.Construct, id *string, props *ContainerCompu
 	}
 }
 
-func createLbSecurityGroup(scope constructs.Construct, id *string, props *securityGroupProps, vpc ec2.IVpc) ec2.ISecurityGroup {
+// addDefaultCapacityProviderStrategy sets the strategy on the capacity provider
+// associations of the cluster. The L2 cluster does not expose it and only
+// creates the associations resource from an aspect at synth time, so the
+// strategy is applied from an aspect as well.
+func addDefaultCapacityProviderStrategy(cluster ecs.Cluster, strategies []CapacityProviderStrategyProps, capacityProviders map[string]ecs.AsgCapacityProvider) {
+	var defaultStrategy []interface{}
+	for _, strategy := range strategies {
+		capacityProvider := jsii.String(strategy.CapacityProvider)
+		if asgCapacityProvider, ok := capacityProviders[strategy.CapacityProvider]; ok {
+			capacityProvider = asgCapacityProvider.CapacityProviderName()
+		}
+		defaultStrategy = append(defaultStrategy, map[string]interface{}{
+			"CapacityProvider": capacityProvider,
+			"Base":             strategy.Base,
+			"Weight":           strategy.Weight,
+		})
+	}
+	awscdk.Aspects_Of(cluster).Add(&capacityProviderStrategyAspect{defaultStrategy})
+}
+
+type capacityProviderStrategyAspect struct {
+	defaultStrategy []interface{}
+}
+
+func (a *capacityProviderStrategyAspect) Visit(node constructs.IConstruct) {
+	resource, ok := node.(awscdk.CfnResource)
+	if ok && *resource.CfnResourceType() == "AWS::ECS::ClusterCapacityProviderAssociations" {
+		resource.AddPropertyOverride(jsii.String("DefaultCapacityProviderStrategy"), a.defaultStrategy)
+	}
+}
+
+func isFargateCapacityProvider(name string) bool {
+	return name == FargateCapacityProvider || name == FargateSpotCapacityProvider
+}
+
+// lbIngressPeers returns who may reach the load balancer. Without an explicit
+// allow list an internet-facing ALB stays open to the world and an internal
+// one to its VPC.
+func lbIngressPeers(props *ContainerComputeLoadBalancerProps, vpc ec2.IVpc) []ec2.IPeer {
+	var peers []ec2.IPeer
+	for _, cidr := range props.AllowedCidrs {
+		peers = append(peers, ec2.Peer_Ipv4(jsii.String(cidr)))
+	}
+	for _, prefixList := range props.AllowedPrefixLists {
+		peers = append(peers, ec2.Peer_PrefixList(jsii.String(prefixList)))
+	}
+	if len(peers) > 0 {
+		return peers
+	}
+	if props.Internal {
+		return []ec2.IPeer{ec2.Peer_Ipv4(vpc.VpcCidrBlock())}
+	}
+	return []ec2.IPeer{ec2.Peer_AnyIpv4()}
+}
+
+func lbSubnets(scope constructs.Construct, props *ContainerComputeLoadBalancerProps) *ec2.SubnetSelection {
+	if len(props.SubnetIds) > 0 {
+		var subnets []ec2.ISubnet
+		for _, subnetId := range props.SubnetIds {
+			subnets = append(subnets, ec2.Subnet_FromSubnetId(scope, jsii.String(props.Name+subnetId), jsii.String(subnetId)))
+		}
+		return &ec2.SubnetSelection{Subnets: &subnets}
+	}
+
+	subnetType := props.SubnetType
+	if subnetType == "" {
+		subnetType = ec2.SubnetType_PUBLIC
+		if props.Internal {
+			subnetType = ec2.SubnetType_PRIVATE_WITH_EGRESS
+		}
+	}
+	return &ec2.SubnetSelection{SubnetType: subnetType}
+}
+
+func createLbSecurityGroup(scope constructs.Construct, id *string, props *securityGroupProps, vpc ec2.IVpc, peers []ec2.IPeer) ec2.ISecurityGroup {
 	lbSecurityGroup := ec2.NewSecurityGroup(scope, id, &ec2.SecurityGroupProps{
 		AllowAllOutbound:  jsii.Bool(true),
 		Vpc:               vpc,
@@ -168,70 +744,159 @@ func createLbSecurityGroup(scope constructs.Construct, id *string, props *securi
 		Description:       &props.Description,
 	})
 
-	lbSecurityGroup.AddIngressRule(
-		ec2.Peer_AnyIpv4(),
-		ec2.Port_Tcp(jsii.Number(443)),
-		jsii.String("Default HTTPS Port"),
-		jsii.Bool(false),
-	)
+	for _, peer := range peers {
+		lbSecurityGroup.AddIngressRule(
+			peer,
+			ec2.Port_Tcp(jsii.Number(443)),
+			jsii.String("Default HTTPS Port"),
+			jsii.Bool(false),
+		)
 
-	lbSecurityGroup.AddIngressRule(
-		ec2.Peer_AnyIpv4(),
-		ec2.Port_Tcp(jsii.Number(80)),
-		jsii.String("Default HTTP Port"),
-		jsii.Bool(false),
-	)
+		lbSecurityGroup.AddIngressRule(
+			peer,
+			ec2.Port_Tcp(jsii.Number(80)),
+			jsii.String("Default HTTP Port"),
+			jsii.Bool(false),
+		)
+	}
 
 	return lbSecurityGroup
 }
 
-func createLoadBalancer(scope constructs.Construct, id *string, props *ContainerComputeLoadBalancerProps) elbv2.IApplicationLoadBalancer {
-	lb := elbv2.NewApplicationLoadBalancer(scope, id, &elbv2.ApplicationLoadBalancerProps{
-		LoadBalancerName: jsii.String(props.Name),
-		Vpc:              vpc,
-		InternetFacing:   jsii.Bool(true),
-		VpcSubnets:       &ec2.SubnetSelection{SubnetType: ec2.SubnetType_PUBLIC},
-		IdleTimeout:      awscdk.Duration_Seconds(jsii.Number(120)),
-		IpAddressType:    elbv2.IpAddressType_IPV4,
-		SecurityGroup: createLbSecurityGroup(scope, jsii.String(props.Name+"SecurityGroup"), &securityGroupProps{
-			Name:        props.Name + "SecurityGroup",
-			Description: "Security group for " + props.Name,
-		},
-			vpc,
-		),
-	})
+func createLoadBalancer(scope constructs.Construct, id *string, props *ContainerComputeLoadBalancerProps, vpc ec2.IVpc, securityGroup ec2.ISecurityGroup) elbv2.ApplicationLoadBalancer {
+	hardening := &props.Hardening
+	lbProps := &elbv2.ApplicationLoadBalancerProps{
+		LoadBalancerName:        jsii.String(props.Name),
+		Vpc:                     vpc,
+		InternetFacing:          jsii.Bool(!props.Internal),
+		VpcSubnets:              lbSubnets(scope, props),
+		IdleTimeout:             awscdk.Duration_Seconds(jsii.Number(hardening.idleTimeout())),
+		IpAddressType:           elbv2.IpAddressType_IPV4,
+		SecurityGroup:           securityGroup,
+		DeletionProtection:      jsii.Bool(hardening.DeletionProtection),
+		Http2Enabled:            jsii.Bool(!hardening.DisableHttp2),
+		DropInvalidHeaderFields: jsii.Bool(hardening.DropInvalidHeaderFields),
+	}
+	if hardening.DesyncMitigationMode != "" {
+		lbProps.DesyncMitigationMode = hardening.DesyncMitigationMode
+	}
+	lb := elbv2.NewApplicationLoadBalancer(scope, id, lbProps)
 	return lb
 }
 
-func createHttpsListener(scope constructs.Construct, id *string, props *ContainerComputeLoadBalancerProps, lb elbv2.IApplicationLoadBalancer) elbv2.IApplicationListener {
-	httpsListener := elbv2.NewApplicationListener(scope, jsii.String("LoadbalancerHttpsListener"), &elbv2.ApplicationListenerProps{
-		LoadBalancer: lb,
-		Certificates: &[]elbv2.IListenerCertificate{
-			elbv2.ListenerCertificate_FromArn(jsii.String(props.ListenerCertificateArn))},
-		Protocol: elbv2.ApplicationProtocol_HTTPS,
-		Port:     jsii.Number(443),
-		DefaultTargetGroups: &[]elbv2.IApplicationTargetGroup{
-			elbv2.NewApplicationTargetGroup(
-				scope,
-				jsii.String("DefaultTargetGroup"),
-				&elbv2.ApplicationTargetGroupProps{
-					TargetGroupName: jsii.String(props.Name + "DefaultTargetGroup"),
-					TargetType:      elbv2.TargetType_INSTANCE,
-					Vpc:             vpc,
-					Protocol:        elbv2.ApplicationProtocol_HTTP,
-					Port:            jsii.Number(8080),
-				},
-			),
-		},
+func createHostedZone(scope constructs.Construct, id *string, props *ContainerComputeHostedZoneProps) route53.IHostedZone {
+	if props.Id == "" {
+		return route53.HostedZone_FromLookup(scope, id, &route53.HostedZoneProviderProps{
+			DomainName: jsii.String(props.Name),
+		})
+	}
+	return route53.HostedZone_FromHostedZoneAttributes(scope, id, &route53.HostedZoneAttributes{
+		HostedZoneId: jsii.String(props.Id),
+		ZoneName:     jsii.String(props.Name),
 	})
+}
+
+// createListenerCertificates returns the HTTPS listener's certificates. The
+// first one is the default and the listener serves the rest through SNI.
+func createListenerCertificates(scope constructs.Construct, id *string, props *ContainerComputeLoadBalancerProps, hostedZone route53.IHostedZone) []elbv2.IListenerCertificate {
+	var certificates []elbv2.IListenerCertificate
+	for _, arn := range append([]string{props.ListenerCertificateArn}, props.CertificateArns...) {
+		if arn != "" {
+			certificates = append(certificates, elbv2.ListenerCertificate_FromArn(jsii.String(arn)))
+		}
+	}
+	for _, domainName := range props.CertificateDomainNames {
+		certificate := acm.NewCertificate(scope, jsii.String(*id+domainName), &acm.CertificateProps{
+			DomainName: jsii.String(domainName),
+			Validation: acm.CertificateValidation_FromDns(hostedZone),
+		})
+		certificates = append(certificates, elbv2.ListenerCertificate_FromCertificateManager(certificate))
+	}
+	return certificates
+}
+
+func createHttpsListener(scope constructs.Construct, id *string, props *ContainerComputeLoadBalancerProps, lb elbv2.IApplicationLoadBalancer, certificates []elbv2.IListenerCertificate, defaultTargetGroups map[string]elbv2.ApplicationTargetGroup) elbv2.IApplicationListener {
+	listenerProps := &elbv2.ApplicationListenerProps{
+		LoadBalancer:  lb,
+		Certificates:  &certificates,
+		Protocol:      elbv2.ApplicationProtocol_HTTPS,
+		Port:          jsii.Number(443),
+		Open:          jsii.Bool(false),
+		DefaultAction: createDefaultAction(&props.DefaultAction, defaultTargetGroups),
+	}
+	if props.Hardening.SslPolicy != "" {
+		listenerProps.SslPolicy = props.Hardening.SslPolicy
+	}
+	httpsListener := elbv2.NewApplicationListener(scope, jsii.String("LoadbalancerHttpsListener"), listenerProps)
 	return httpsListener
 }
 
+func createDefaultAction(props *ContainerComputeDefaultActionProps, defaultTargetGroups map[string]elbv2.ApplicationTargetGroup) elbv2.ListenerAction {
+	switch props.Type {
+	case DefaultActionRedirect:
+		return elbv2.ListenerAction_Redirect(&elbv2.RedirectOptions{
+			Host:      jsii.String(defaultString(props.RedirectHost, "#{host}")),
+			Path:      jsii.String(defaultString(props.RedirectPath, "/#{path}")),
+			Port:      jsii.String(defaultString(props.RedirectPort, "#{port}")),
+			Protocol:  jsii.String(defaultString(props.RedirectProtocol, "#{protocol}")),
+			Query:     jsii.String(defaultString(props.RedirectQuery, "#{query}")),
+			Permanent: jsii.Bool(props.Permanent),
+		})
+	case DefaultActionForward:
+		return elbv2.ListenerAction_Forward(&[]elbv2.IApplicationTargetGroup{defaultTargetGroups[props.ServiceName]}, &elbv2.ForwardOptions{})
+	default:
+		statusCode := props.StatusCode
+		if statusCode == 0 {
+			statusCode = 404
+		}
+		options := &elbv2.FixedResponseOptions{
+			ContentType: jsii.String(defaultString(props.ContentType, "text/plain")),
+		}
+		if props.MessageBody != "" {
+			options.MessageBody = jsii.String(props.MessageBody)
+		}
+		return elbv2.ListenerAction_FixedResponse(jsii.Number(statusCode), options)
+	}
+}
+
+// createDefaultTargetGroup creates the target group for the service the
+// listener forwards to by default. The service registers its tasks through
+// AttachService when it is created.
+func createDefaultTargetGroup(scope constructs.Construct, id *string, props *ContainerComputeDefaultActionProps, vpc ec2.IVpc) elbv2.ApplicationTargetGroup {
+	tg := elbv2.NewApplicationTargetGroup(scope, id, &elbv2.ApplicationTargetGroupProps{
+		TargetGroupName: jsii.String(props.ServiceName),
+		HealthCheck:     targetGroupHealthCheck(props.HealthCheckPath, props.HealthyHttpCodes),
+		TargetType:      elbv2.TargetType_IP,
+		Vpc:             vpc,
+		Protocol:        elbv2.ApplicationProtocol_HTTP,
+	})
+	return tg
+}
+
+// targetGroupHealthCheck checks path every 30 seconds, "/" and 200 unless
+// given.
+func targetGroupHealthCheck(path, healthyHttpCodes string) *elbv2.HealthCheck {
+	return &elbv2.HealthCheck{
+		Enabled:          jsii.Bool(true),
+		HealthyHttpCodes: jsii.String(defaultString(healthyHttpCodes, "200")),
+		Path:             jsii.String(defaultString(path, "/")),
+		Interval:         awscdk.Duration_Seconds(jsii.Number(30)),
+	}
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
 func createHttpListener(scope constructs.Construct, id *string, lb elbv2.IApplicationLoadBalancer) {
 
 	elbv2.NewApplicationListener(scope, jsii.String("LoadbalancerHttpListener"), &elbv2.ApplicationListenerProps{
 		Port:         jsii.Number(80),
 		LoadBalancer: lb,
+		Open:         jsii.Bool(false),
 		DefaultAction: elbv2.ListenerAction_Redirect(
 			&elbv2.RedirectOptions{
 				Host:      jsii.String("#{host}"),
@@ -244,7 +909,7 @@ func createHttpListener(scope constructs.Construct, id *string, lb elbv2.IApplic
 	})
 }
 
-func createCloudMapNamespace(scope constructs.Construct, id *string, props *ContainerComputeCloudmapNamespaceProps) servicediscovery.IPrivateDnsNamespace {
+func createCloudMapNamespace(scope constructs.Construct, id *string, props *ContainerComputeCloudmapNamespaceProps, vpc ec2.IVpc) servicediscovery.IPrivateDnsNamespace {
 	cloudmapNamespace := servicediscovery.NewPrivateDnsNamespace(scope, id, &servicediscovery.PrivateDnsNamespaceProps{
 		Name:        jsii.String(props.Name),
 		Description: jsii.String(props.Description),
@@ -253,7 +918,7 @@ func createCloudMapNamespace(scope constructs.Construct, id *string, props *Cont
 	return cloudmapNamespace
 }
 
-func createAsgSecurityGroup(scope constructs.Construct, id *string, props *securityGroupProps) ec2.ISecurityGroup {
+func createAsgSecurityGroup(scope constructs.Construct, id *string, props *securityGroupProps, vpc ec2.IVpc) ec2.ISecurityGroup {
 	asgSecurityGroup := ec2.NewSecurityGroup(scope, id, &ec2.SecurityGroupProps{
 		AllowAllOutbound:  jsii.Bool(true),
 		Vpc:               vpc,
@@ -263,95 +928,300 @@ func createAsgSecurityGroup(scope constructs.Construct, id *string, props *secur
 	return asgSecurityGroup
 }
 
-func createAsgPolicyDocument() iam.PolicyDocument {
+// ebsVolumePolicyName is reserved for the rexray/ebs plugin permissions.
+const ebsVolumePolicyName = "Ec2VolumeAccess"
+
+func createAsgPolicyDocuments(props *ContainerComputeAsgProps) map[string]iam.PolicyDocument {
+	policies := make(map[string]iam.PolicyDocument)
+	for name, policyDocument := range props.Role.InlinePolicies {
+		policies[name] = policyDocument
+	}
+	if rexrayEbsPluginEnabled(props) {
+		policies[ebsVolumePolicyName] = createEbsVolumePolicyDocument(props.Name)
+	}
+	return policies
+}
+
+// createEbsVolumePolicyDocument lets the rexray/ebs plugin create volumes and
+// manage the ones it has named with a Name tag, and attach them only to
+// instances of the group. Describe calls have no resource-level permissions.
+func createEbsVolumePolicyDocument(asgName string) iam.PolicyDocument {
+	arnPrefix := "arn:" + *awscdk.Aws_PARTITION() + ":ec2:" + *awscdk.Aws_REGION() + ":" + *awscdk.Aws_ACCOUNT_ID()
+	volumes := jsii.Strings(arnPrefix + ":volume/*")
+
 	pd := iam.NewPolicyDocument(&iam.PolicyDocumentProps{
-		Statements: &[]iam.PolicyStatement{iam.NewPolicyStatement(&iam.PolicyStatementProps{Effect: iam.Effect_ALLOW,
-			Actions: &[]*string{
-				jsii.String("ec2:AttachVolume"),
-				jsii.String("ec2:CreateVolume"),
-				jsii.String("ec2:DeleteVolume"),
-				jsii.String("ec2:DescribeAvailabilityZones"),
-				jsii.String("ec2:DescribeInstances"),
-				jsii.String("ec2:DescribeVolumes"),
-				jsii.String("ec2:DescribeVolumeAttribute"),
-				jsii.String("ec2:DetachVolume"),
-				jsii.String("ec2:DescribeVolumeStatus"),
-				jsii.String("ec2:ModifyVolumeAttribute"),
-				jsii.String("ec2:DescribeTags"),
-				jsii.String("ec2:CreateTags"),
-			},
-			Resources: &[]*string{jsii.String("*")}})},
+		Statements: &[]iam.PolicyStatement{
+			iam.NewPolicyStatement(&iam.PolicyStatementProps{
+				Actions: jsii.Strings(
+					"ec2:DescribeAvailabilityZones",
+					"ec2:DescribeInstances",
+					"ec2:DescribeTags",
+					"ec2:DescribeVolumes",
+					"ec2:DescribeVolumeAttribute",
+					"ec2:DescribeVolumeStatus",
+				),
+				Resources: jsii.Strings("*"),
+			}),
+			iam.NewPolicyStatement(&iam.PolicyStatementProps{
+				Actions:   jsii.Strings("ec2:CreateVolume"),
+				Resources: volumes,
+			}),
+			iam.NewPolicyStatement(&iam.PolicyStatementProps{
+				Actions:   jsii.Strings("ec2:CreateTags"),
+				Resources: volumes,
+				Conditions: &map[string]interface{}{
+					"ForAllValues:StringEquals": map[string]interface{}{"aws:TagKeys": []string{"Name"}},
+				},
+			}),
+			iam.NewPolicyStatement(&iam.PolicyStatementProps{
+				Actions: jsii.Strings(
+					"ec2:AttachVolume",
+					"ec2:DetachVolume",
+					"ec2:DeleteVolume",
+					"ec2:ModifyVolumeAttribute",
+				),
+				Resources: volumes,
+				Conditions: &map[string]interface{}{
+					"Null": map[string]interface{}{"aws:ResourceTag/Name": "false"},
+				},
+			}),
+			iam.NewPolicyStatement(&iam.PolicyStatementProps{
+				Actions:   jsii.Strings("ec2:AttachVolume", "ec2:DetachVolume"),
+				Resources: jsii.Strings(arnPrefix + ":instance/*"),
+				Conditions: &map[string]interface{}{
+					"StringEquals": map[string]interface{}{"aws:ResourceTag/aws:autoscaling:groupName": asgName},
+				},
+			}),
+		},
 	})
 	return pd
 }
 
-func createAsgRole(scope constructs.Construct, id *string, props *ContainerComputeAsgProps, policyDocument iam.PolicyDocument) iam.IRole {
+func createAsgRole(scope constructs.Construct, id *string, props *ContainerComputeAsgProps, policyDocuments map[string]iam.PolicyDocument) iam.IRole {
+	var managedPolicies []iam.IManagedPolicy
+	for i, arn := range props.Role.ManagedPolicyArns {
+		managedPolicies = append(managedPolicies, iam.ManagedPolicy_FromManagedPolicyArn(scope, jsii.String(*id+"ManagedPolicy"+strconv.Itoa(i)), jsii.String(arn)))
+	}
+	var permissionsBoundary iam.IManagedPolicy
+	if props.Role.PermissionsBoundaryArn != "" {
+		permissionsBoundary = iam.ManagedPolicy_FromManagedPolicyArn(scope, jsii.String(*id+"PermissionsBoundary"), jsii.String(props.Role.PermissionsBoundaryArn))
+	}
+
 	role := iam.NewRole(scope, id, &iam.RoleProps{
-		Description:    jsii.String("Iam role for autoscaling group " + props.Name),
-		InlinePolicies: &map[string]iam.PolicyDocument{"Ec2VolumeAccess": policyDocument},
-		RoleName:       jsii.String(props.Name + "InstanceProfileRole"),
-		AssumedBy:      iam.NewServicePrincipal(jsii.String("ec2.amazonaws.com"), &iam.ServicePrincipalOpts{}),
+		Description:         jsii.String("Iam role for autoscaling group " + props.Name),
+		InlinePolicies:      &policyDocuments,
+		ManagedPolicies:     &managedPolicies,
+		PermissionsBoundary: permissionsBoundary,
+		RoleName:            jsii.String(props.Name + "InstanceProfileRole"),
+		AssumedBy:           iam.NewServicePrincipal(jsii.String("ec2.amazonaws.com"), &iam.ServicePrincipalOpts{}),
 	})
 	return role
 }
 
-func createAutoScalingGroup(scope constructs.Construct, id *string, props *ContainerComputeAsgProps, clusterName string) autoscaling.IAutoScalingGroup {
-	asgPolicyDocument := createAsgPolicyDocument()
+func createAutoScalingGroup(scope constructs.Construct, id *string, props *ContainerComputeAsgProps, vpc ec2.IVpc, securityGroup ec2.ISecurityGroup, role iam.IRole) autoscaling.IAutoScalingGroup {
+	subnetType := props.SubnetType
+	if subnetType == "" {
+		subnetType = ec2.SubnetType_PUBLIC
+	}
 
-	role := createAsgRole(scope, jsii.String("IamRole"+props.Name), props, asgPolicyDocument)
+	launchTemplate := createLaunchTemplate(scope, jsii.String(*id+"LaunchTemplate"), props, securityGroup, role)
 
-	asg := autoscaling.NewAutoScalingGroup(scope, id, &autoscaling.AutoScalingGroupProps{
+	asgProps := &autoscaling.AutoScalingGroupProps{
 		AutoScalingGroupName: jsii.String(props.Name),
 		MinCapacity:          jsii.Number(props.MinCapacity),
 		MaxCapacity:          jsii.Number(props.MaxCapacity),
-		InstanceType:         ec2.InstanceType_Of(props.InstanceClass, props.InstanceSize),
-		MachineImage:         createMachineImage(),
-		SecurityGroup: createAsgSecurityGroup(scope, jsii.String(props.Name+"SecurityGroup"), &securityGroupProps{
-			Name:        props.Name + "SecurityGroup",
-			Description: "SecurityGroup for " + props.Name,
-			vpc:         vpc,
-		}),
-
-		UserData:   ec2.UserData_ForLinux(&ec2.LinuxUserDataOptions{Shebang: jsii.String("#!/bin/bash")}),
-		VpcSubnets: &ec2.SubnetSelection{SubnetType: ec2.SubnetType_PUBLIC},
-		Vpc:        vpc,
-		KeyName:    jsii.String(props.SshKeyName),
-		Role:       role,
-	})
+		VpcSubnets:           &ec2.SubnetSelection{SubnetType: subnetType},
+		Vpc:                  vpc,
+	}
+	if props.MixedInstances.Enabled {
+		asgProps.MixedInstancesPolicy = createMixedInstancesPolicy(props, launchTemplate)
+	} else {
+		asgProps.LaunchTemplate = launchTemplate
+	}
 
-	asg.UserData().AddCommands(
-		jsii.String("sudo yum -y update"),
-		jsii.String("sudo yum -y install wget"),
-		jsii.String("sudo touch /etc/ecs/ecs.config"),
-		jsii.String("sudo amazon-linux-extras disable docker"),
-		jsii.String("sudo amazon-linux-extras install -y ecs"),
-		jsii.String("echo \"ECS_CLUSTER="+clusterName+"\" >>  /etc/ecs/ecs.config"),
-		jsii.String("echo \"ECS_AWSVPC_BLOCK_IMDS=true\" >> /etc/ecs/ecs.config"),
-		jsii.String("sudo systemctl enable --now --no-block ecs.service"),
-		jsii.String("docker plugin install rexray/ebs REXRAY_PREEMPT=true EBS_REGION="+*awscdk.Aws_REGION()+" --grant-all-permissions"),
-	)
+	asg := autoscaling.NewAutoScalingGroup(scope, id, asgProps)
+	if props.MixedInstances.usesSpot() {
+		asg.Node().DefaultChild().(autoscaling.CfnAutoScalingGroup).SetCapacityRebalance(jsii.Bool(true))
+	}
 	return asg
 }
 
-func createMachineImage() ec2.IMachineImage {
-	image := ec2.NewAmazonLinuxImage(&ec2.AmazonLinuxImageProps{
-		CpuType:        ec2.AmazonLinuxCpuType_X86_64,
-		Edition:        ec2.AmazonLinuxEdition_STANDARD,
-		Generation:     ec2.AmazonLinuxGeneration_AMAZON_LINUX_2,
-		Virtualization: ec2.AmazonLinuxVirt_HVM,
-		Kernel:         ec2.AmazonLinuxKernel_KERNEL5_X,
+func createLaunchTemplate(scope constructs.Construct, id *string, props *ContainerComputeAsgProps, securityGroup ec2.ISecurityGroup, role iam.IRole) ec2.LaunchTemplate {
+	httpTokens := ec2.LaunchTemplateHttpTokens_REQUIRED
+	if props.AllowImdsv1 {
+		httpTokens = ec2.LaunchTemplateHttpTokens_OPTIONAL
+	}
+	hopLimit := props.MetadataHopLimit
+	if hopLimit == 0 {
+		hopLimit = 2
+	}
+
+	launchTemplate := ec2.NewLaunchTemplate(scope, id, &ec2.LaunchTemplateProps{
+		LaunchTemplateName:      jsii.String(props.Name),
+		InstanceType:            ec2.InstanceType_Of(props.InstanceClass, props.InstanceSize),
+		MachineImage:            createMachineImage(scope, &props.MachineImage, instanceArchitecture(props)),
+		SecurityGroup:           securityGroup,
+		UserData:                createUserData(&props.MachineImage),
+		KeyName:                 props.Access.KeyName(),
+		Role:                    role,
+		HttpEndpoint:            jsii.Bool(true),
+		HttpTokens:              httpTokens,
+		HttpPutResponseHopLimit: jsii.Number(hopLimit),
+		DetailedMonitoring:      jsii.Bool(props.DetailedMonitoring),
+		BlockDevices:            &[]*ec2.BlockDevice{createRootVolume(scope, props)},
 	})
-	return image
+	return launchTemplate
+}
+
+// createRootVolume sizes the volume that holds images and container layers:
+// the root volume on Amazon Linux and the data volume on Bottlerocket.
+func createRootVolume(scope constructs.Construct, props *ContainerComputeAsgProps) *ec2.BlockDevice {
+	deviceName := "/dev/xvda"
+	if props.MachineImage.Family == MachineImageBottlerocket {
+		deviceName = "/dev/xvdb"
+	}
+
+	volume := &props.RootVolume
+	size := volume.SizeGiB
+	if size == 0 {
+		size = 30
+	}
+	volumeType := volume.VolumeType
+	if volumeType == "" {
+		volumeType = ec2.EbsDeviceVolumeType_GP3
+	}
+
+	options := &ec2.EbsDeviceOptions{
+		VolumeType:          volumeType,
+		Encrypted:           jsii.Bool(true),
+		DeleteOnTermination: jsii.Bool(true),
+	}
+	if volume.Iops != 0 {
+		options.Iops = jsii.Number(volume.Iops)
+	}
+	if volume.KmsKeyArn != "" {
+		options.KmsKey = kms.Key_FromKeyArn(scope, jsii.String(props.Name+"RootVolumeKey"), jsii.String(volume.KmsKeyArn))
+	}
+
+	return &ec2.BlockDevice{
+		DeviceName: jsii.String(deviceName),
+		Volume:     ec2.BlockDeviceVolume_Ebs(jsii.Number(size), options),
+	}
 }
 
-func createCapacityProvider(scope constructs.Construct, id *string, props *ContainerComputeAsgCapacityProviderProps, asg autoscaling.IAutoScalingGroup) ecs.AsgCapacityProvider {
+func createMixedInstancesPolicy(props *ContainerComputeAsgProps, launchTemplate ec2.ILaunchTemplate) *autoscaling.MixedInstancesPolicy {
+	overrides := []*autoscaling.LaunchTemplateOverrides{{
+		InstanceType: ec2.InstanceType_Of(props.InstanceClass, props.InstanceSize),
+	}}
+	for _, instanceType := range props.MixedInstances.InstanceTypes {
+		overrides = append(overrides, &autoscaling.LaunchTemplateOverrides{
+			InstanceType: ec2.InstanceType_Of(instanceType.InstanceClass, instanceType.InstanceSize),
+		})
+	}
+
+	spotAllocationStrategy := props.MixedInstances.SpotAllocationStrategy
+	if spotAllocationStrategy == "" {
+		spotAllocationStrategy = autoscaling.SpotAllocationStrategy_PRICE_CAPACITY_OPTIMIZED
+	}
+
+	return &autoscaling.MixedInstancesPolicy{
+		LaunchTemplate:          launchTemplate,
+		LaunchTemplateOverrides: &overrides,
+		InstancesDistribution: &autoscaling.InstancesDistribution{
+			OnDemandBaseCapacity:                jsii.Number(props.MixedInstances.OnDemandBaseCapacity),
+			OnDemandPercentageAboveBaseCapacity: jsii.Number(100 - props.MixedInstances.SpotPercentage),
+			SpotAllocationStrategy:              spotAllocationStrategy,
+		},
+	}
+}
+
+func instanceArchitecture(props *ContainerComputeAsgProps) ec2.InstanceArchitecture {
+	return ec2.InstanceType_Of(props.InstanceClass, props.InstanceSize).Architecture()
+}
+
+func createMachineImage(scope constructs.Construct, props *ContainerComputeMachineImageProps, architecture ec2.InstanceArchitecture) ec2.IMachineImage {
+	arm := architecture == ec2.InstanceArchitecture_ARM_64
+
+	switch props.Family {
+	case MachineImageEcsAmazonLinux2023:
+		parameter := "/aws/service/ecs/optimized-ami/amazon-linux-2023/recommended/image_id"
+		if arm {
+			parameter = "/aws/service/ecs/optimized-ami/amazon-linux-2023/arm64/recommended/image_id"
+		}
+		return ec2.MachineImage_FromSsmParameter(jsii.String(parameter), &ec2.SsmParameterImageOptions{Os: ec2.OperatingSystemType_LINUX})
+	case MachineImageBottlerocket:
+		return ecs.NewBottleRocketImage(&ecs.BottleRocketImageProps{
+			Architecture: architecture,
+			Variant:      ecs.BottlerocketEcsVariant_AWS_ECS_1,
+		})
+	case MachineImageCustom:
+		if props.SsmParameter != "" {
+			return ec2.MachineImage_FromSsmParameter(jsii.String(props.SsmParameter), &ec2.SsmParameterImageOptions{Os: ec2.OperatingSystemType_LINUX})
+		}
+		// An AMI id only holds in one region, so the stack has to name it.
+		region := awscdk.Stack_Of(scope).Region()
+		if *awscdk.Token_IsUnresolved(region) {
+			scope.Node().AddValidation(&propsValidation{validate: func() []string {
+				return []string{"MachineImage.AmiId needs a stack with an explicit region, use MachineImage.SsmParameter otherwise"}
+			}})
+			return ec2.MachineImage_GenericLinux(&map[string]*string{}, &ec2.GenericLinuxImageProps{})
+		}
+		return ec2.MachineImage_GenericLinux(&map[string]*string{
+			*region: jsii.String(props.AmiId),
+		}, &ec2.GenericLinuxImageProps{})
+	default:
+		hardwareType := ecs.AmiHardwareType_STANDARD
+		if arm {
+			hardwareType = ecs.AmiHardwareType_ARM
+		}
+		return ecs.EcsOptimizedImage_AmazonLinux2(hardwareType, &ecs.EcsOptimizedImageOptions{})
+	}
+}
+
+func machineImageType(props *ContainerComputeMachineImageProps) ecs.MachineImageType {
+	if props.Family == MachineImageBottlerocket {
+		return ecs.MachineImageType_BOTTLEROCKET
+	}
+	return ecs.MachineImageType_AMAZON_LINUX_2
+}
+
+// createUserData returns a shell script for the Amazon Linux families. Bottlerocket
+// reads TOML settings instead, so its user data starts out empty.
+func createUserData(props *ContainerComputeMachineImageProps) ec2.UserData {
+	if props.Family == MachineImageBottlerocket {
+		return ec2.UserData_Custom(jsii.String(""))
+	}
+	return ec2.UserData_ForLinux(&ec2.LinuxUserDataOptions{Shebang: jsii.String("#!/bin/bash")})
+}
+
+func createCapacityProvider(scope constructs.Construct, id *string, props *ContainerComputeAsgCapacityProviderProps, asg autoscaling.IAutoScalingGroup, imageType ecs.MachineImageType) ecs.AsgCapacityProvider {
+	targetCapacityPercent := props.TargetCapacityPercent
+	if targetCapacityPercent == 0 {
+		targetCapacityPercent = 100
+	}
+	var minimumScalingStepSize, maximumScalingStepSize *float64
+	if props.MinimumScalingStepSize != 0 {
+		minimumScalingStepSize = jsii.Number(props.MinimumScalingStepSize)
+	}
+	if props.MaximumScalingStepSize != 0 {
+		maximumScalingStepSize = jsii.Number(props.MaximumScalingStepSize)
+	}
+
 	asgCapacityProvider := ecs.NewAsgCapacityProvider(scope, id, &ecs.AsgCapacityProviderProps{
 		AutoScalingGroup:                   asg,
+		MachineImageType:                   imageType,
 		EnableManagedScaling:               jsii.Bool(true),
-		EnableManagedTerminationProtection: jsii.Bool(false),
-		TargetCapacityPercent:              jsii.Number(100),
+		EnableManagedTerminationProtection: jsii.Bool(props.EnableManagedTerminationProtection),
+		TargetCapacityPercent:              jsii.Number(targetCapacityPercent),
+		MinimumScalingStepSize:             minimumScalingStepSize,
+		MaximumScalingStepSize:             maximumScalingStepSize,
 		CapacityProviderName:               jsii.String(props.Name),
-		CanContainersAccessInstanceRole:    jsii.Bool(true),
+		CanContainersAccessInstanceRole:    jsii.Bool(!props.BlockContainerInstanceRoleAccess),
 	})
+
+	if props.InstanceWarmupSeconds != 0 {
+		cfnCapacityProvider := asgCapacityProvider.Node().FindChild(id).(awscdk.CfnResource)
+		cfnCapacityProvider.AddPropertyOverride(jsii.String("AutoScalingGroupProvider.ManagedScaling.InstanceWarmupPeriod"), jsii.Number(props.InstanceWarmupSeconds))
+	}
 	return asgCapacityProvider
 }
@@ -0,0 +1,802 @@
+package breezeware
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const testCertificateArn = "arn:aws:acm:us-east-1:123456789012:certificate/0b1d6f3e-2a7c-4e8b-9f10-3c5d7e9a1b2c"
+
+// newTestStack returns a stack with a concrete account and region, which VPC
+// lookups and region-specific resources need. The availability zones are
+// seeded so that zone names resolve the way they do against a real account.
+func newTestStack() awscdk.Stack {
+	app := awscdk.NewApp(&awscdk.AppProps{
+		Context: &map[string]interface{}{
+			"availability-zones:account=123456789012:region=us-east-1": []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+		},
+	})
+	return awscdk.NewStack(app, jsii.String("TestStack"), &awscdk.StackProps{
+		Env: &awscdk.Environment{
+			Account: jsii.String("123456789012"),
+			Region:  jsii.String("us-east-1"),
+		},
+	})
+}
+
+// testComputeProps returns the smallest compute with its own VPC and one Auto
+// Scaling group capacity provider. Tests change what they are about.
+func testComputeProps() ContainerComputeProps {
+	return ContainerComputeProps{
+		Vpc: ContainerComputeVpcProps{Name: "Vpc"},
+		Cluster: ContainerComputeClusterProps{
+			Name:                         "Cluster",
+			IsAsgCapacityProviderEnabled: true,
+		},
+		AsgCapacityProviders: []AutoscalinGroupCapacityProviders{{
+			AutoScalingGroup: ContainerComputeAsgProps{
+				Name:          "Asg",
+				MaxCapacity:   2,
+				InstanceClass: ec2.InstanceClass_T3,
+				InstanceSize:  ec2.InstanceSize_MICRO,
+			},
+			CapacityProvider: ContainerComputeAsgCapacityProviderProps{Name: "AsgCapacityProvider"},
+		}},
+		LoadBalancer: ContainerComputeLoadBalancerProps{
+			Name:                   "Alb",
+			ListenerCertificateArn: testCertificateArn,
+		},
+		CloudmapNamespace: ContainerComputeCloudmapNamespaceProps{Name: "test.local"},
+	}
+}
+
+// synthConfigCompute loads testConfig for env with the given loadBalancer
+// settings and returns the template of a stack with its compute.
+func synthConfigCompute(t *testing.T, env string, loadBalancer string) assertions.Template {
+	t.Helper()
+	dir := writeTestConfig(t, env, map[string]string{"%INSTANCE_TYPE%": "t3.micro", "%LOAD_BALANCER%": loadBalancer})
+	config, err := LoadEnvironmentConfig(dir, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack := newTestStack()
+	NewContainerCompute(stack, jsii.String("Compute"), &config.Compute)
+	return assertions.Template_FromStack(stack, nil)
+}
+
+func TestContainerComputeOwnsItsVpc(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	first := testComputeProps()
+	first.VpcId = jsii.String("vpc-0123456789abcdef0")
+	second := testComputeProps()
+	second.VpcId = jsii.String("vpc-0fedcba9876543210")
+	second.Cluster.Name = "OtherCluster"
+	second.AsgCapacityProviders[0].AutoScalingGroup.Name = "OtherAsg"
+	second.AsgCapacityProviders[0].CapacityProvider.Name = "OtherAsgCapacityProvider"
+	second.LoadBalancer.Name = "OtherAlb"
+
+	// WHEN
+	computes := []ContainerCompute{
+		NewContainerCompute(stack, jsii.String("Compute"), &first),
+		NewContainerCompute(stack, jsii.String("OtherCompute"), &second),
+	}
+
+	// THEN
+	for _, compute := range computes {
+		if compute.Node().TryFindChild(jsii.String("LookUpVpc")) == nil {
+			t.Errorf("%s has no VPC of its own", *compute.Node().Path())
+		}
+	}
+	assertions.Template_FromStack(stack, nil).ResourceCountIs(jsii.String("AWS::ECS::Cluster"), jsii.Number(2))
+}
+
+func TestContainerComputeVpc(t *testing.T) {
+	tests := []struct {
+		name        string
+		vpcId       *string
+		vpc         ContainerComputeVpcProps
+		cidr        string
+		subnets     float64
+		natGateways float64
+	}{
+		{
+			name:        "created with defaults",
+			vpc:         ContainerComputeVpcProps{Name: "Vpc"},
+			cidr:        "10.0.0.0/16",
+			subnets:     6,
+			natGateways: 1,
+		},
+		{
+			name:        "created with settings",
+			vpc:         ContainerComputeVpcProps{Name: "Vpc", Cidr: "10.20.0.0/16", MaxAzs: 3, NatGateways: 3},
+			cidr:        "10.20.0.0/16",
+			subnets:     9,
+			natGateways: 3,
+		},
+		{
+			name:  "looked up",
+			vpcId: jsii.String("vpc-0123456789abcdef0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := newTestStack()
+			props := testComputeProps()
+			props.VpcId = tt.vpcId
+			props.Vpc = tt.vpc
+
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			template := assertions.Template_FromStack(stack, nil)
+			if tt.vpcId != nil {
+				template.ResourceCountIs(jsii.String("AWS::EC2::VPC"), jsii.Number(0))
+				return
+			}
+			template.HasResourceProperties(jsii.String("AWS::EC2::VPC"), map[string]interface{}{
+				"CidrBlock":          tt.cidr,
+				"EnableDnsHostnames": true,
+				"EnableDnsSupport":   true,
+				"Tags":               assertions.Match_ArrayWith(&[]interface{}{map[string]interface{}{"Key": "Name", "Value": "Vpc"}}),
+			})
+			template.ResourceCountIs(jsii.String("AWS::EC2::Subnet"), jsii.Number(tt.subnets))
+			template.ResourceCountIs(jsii.String("AWS::EC2::NatGateway"), jsii.Number(tt.natGateways))
+		})
+	}
+}
+
+func TestContainerComputeDefaultCapacityProviderStrategy(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.Cluster.IsFargateCapacityProviderEnabled = true
+	props.Cluster.DefaultCapacityProviderStrategy = []CapacityProviderStrategyProps{
+		{CapacityProvider: FargateCapacityProvider, Base: 1, Weight: 1},
+		{CapacityProvider: FargateSpotCapacityProvider, Weight: 3},
+	}
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::ECS::ClusterCapacityProviderAssociations"), map[string]interface{}{
+		"CapacityProviders": assertions.Match_ArrayWith(&[]interface{}{"FARGATE", "FARGATE_SPOT"}),
+		"DefaultCapacityProviderStrategy": []interface{}{
+			map[string]interface{}{"CapacityProvider": "FARGATE", "Base": 1, "Weight": 1},
+			map[string]interface{}{"CapacityProvider": "FARGATE_SPOT", "Base": 0, "Weight": 3},
+		},
+	})
+}
+
+func TestContainerComputeDefaultAsgCapacityProviderStrategy(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.Cluster.DefaultCapacityProviderStrategy = []CapacityProviderStrategyProps{
+		{CapacityProvider: "AsgCapacityProvider", Weight: 1},
+	}
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::ECS::ClusterCapacityProviderAssociations"), map[string]interface{}{
+		"DefaultCapacityProviderStrategy": []interface{}{
+			map[string]interface{}{
+				"CapacityProvider": map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("AsgCapacityProvider"))},
+				"Base":             0,
+				"Weight":           1,
+			},
+		},
+	})
+}
+
+func TestContainerComputeExposesResources(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+
+	// WHEN
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	if compute.LoadBalancerSecurityGroup() == nil {
+		t.Error("LoadBalancerSecurityGroup() = nil")
+	}
+	if _, ok := compute.CapacityProviders()["AsgCapacityProvider"]; !ok {
+		t.Errorf("CapacityProviders() = %v, want the AsgCapacityProvider key", compute.CapacityProviders())
+	}
+	if _, ok := compute.AutoScalingGroups()["Asg"]; !ok {
+		t.Errorf("AutoScalingGroups() = %v, want the Asg key", compute.AutoScalingGroups())
+	}
+	if _, ok := compute.AutoScalingGroupSecurityGroups()["Asg"]; !ok {
+		t.Errorf("AutoScalingGroupSecurityGroups() = %v, want the Asg key", compute.AutoScalingGroupSecurityGroups())
+	}
+	if _, ok := compute.AutoScalingGroupRoles()["Asg"]; !ok {
+		t.Errorf("AutoScalingGroupRoles() = %v, want the Asg key", compute.AutoScalingGroupRoles())
+	}
+}
+
+func TestContainerComputeLoadBalancerPlacement(t *testing.T) {
+	tests := []struct {
+		name         string
+		loadBalancer func(props *ContainerComputeLoadBalancerProps)
+		scheme       string
+		subnets      interface{}
+		ingress      []interface{}
+	}{
+		{
+			name:         "internet-facing",
+			loadBalancer: func(props *ContainerComputeLoadBalancerProps) {},
+			scheme:       "internet-facing",
+			subnets: assertions.Match_ArrayWith(&[]interface{}{
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PublicSubnet1"))},
+			}),
+			ingress: []interface{}{map[string]interface{}{"CidrIp": "0.0.0.0/0"}},
+		},
+		{
+			name:         "internal",
+			loadBalancer: func(props *ContainerComputeLoadBalancerProps) { props.Internal = true },
+			scheme:       "internal",
+			subnets: assertions.Match_ArrayWith(&[]interface{}{
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet1"))},
+			}),
+			ingress: []interface{}{map[string]interface{}{
+				"CidrIp": map[string]interface{}{"Fn::GetAtt": assertions.Match_ArrayWith(&[]interface{}{"CidrBlock"})},
+			}},
+		},
+		{
+			name: "internal with allow lists and subnet ids",
+			loadBalancer: func(props *ContainerComputeLoadBalancerProps) {
+				props.Internal = true
+				props.SubnetIds = []string{"subnet-0123456789abcdef0", "subnet-0123456789abcdef1"}
+				props.AllowedCidrs = []string{"10.1.0.0/16"}
+				props.AllowedPrefixLists = []string{"pl-0123abcd"}
+			},
+			scheme:  "internal",
+			subnets: []interface{}{"subnet-0123456789abcdef0", "subnet-0123456789abcdef1"},
+			ingress: []interface{}{
+				map[string]interface{}{"CidrIp": "10.1.0.0/16"},
+				map[string]interface{}{"SourcePrefixListId": "pl-0123abcd"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			tt.loadBalancer(&props.LoadBalancer)
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+				"Scheme":  tt.scheme,
+				"Subnets": tt.subnets,
+			})
+			for _, peer := range tt.ingress {
+				for _, port := range []float64{80, 443} {
+					rule := map[string]interface{}{"IpProtocol": "tcp", "FromPort": port, "ToPort": port}
+					for key, value := range peer.(map[string]interface{}) {
+						rule[key] = value
+					}
+					// Prefix list peers are rendered as separate ingress resources.
+					if _, ok := rule["SourcePrefixListId"]; ok {
+						template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroupIngress"), rule)
+						continue
+					}
+					template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+						"GroupName":            "AlbSecurityGroup",
+						"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{assertions.Match_ObjectLike(&rule)}),
+					})
+				}
+			}
+		})
+	}
+}
+
+func TestContainerComputeVpcEndpoints(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.VpcEndpoints = ContainerComputeVpcEndpointsProps{Enabled: true}
+	props.AsgCapacityProviders[0].AutoScalingGroup.SubnetType = ec2.SubnetType_PRIVATE_WITH_EGRESS
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::EC2::VPCEndpoint"), jsii.Number(7))
+	for _, service := range []string{"ecs", "ecs-agent", "ecs-telemetry", "ecr.api", "ecr.dkr", "logs"} {
+		template.HasResourceProperties(jsii.String("AWS::EC2::VPCEndpoint"), map[string]interface{}{
+			"ServiceName":       "com.amazonaws.us-east-1." + service,
+			"VpcEndpointType":   "Interface",
+			"PrivateDnsEnabled": true,
+		})
+	}
+	template.HasResourceProperties(jsii.String("AWS::EC2::VPCEndpoint"), map[string]interface{}{
+		"ServiceName":     assertions.Match_AnyValue(),
+		"VpcEndpointType": "Gateway",
+	})
+	template.HasResourceProperties(jsii.String("AWS::AutoScaling::AutoScalingGroup"), map[string]interface{}{
+		"VPCZoneIdentifier": assertions.Match_ArrayWith(&[]interface{}{
+			map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet1"))},
+		}),
+	})
+}
+
+func TestContainerComputeMachineImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		image         ContainerComputeMachineImageProps
+		instanceClass ec2.InstanceClass
+		parameter     string
+		imageId       string
+	}{
+		{
+			name:          "al2 default",
+			instanceClass: ec2.InstanceClass_T3,
+			parameter:     "/aws/service/ecs/optimized-ami/amazon-linux-2/recommended/image_id",
+		},
+		{
+			name:          "al2023 arm64",
+			image:         ContainerComputeMachineImageProps{Family: MachineImageEcsAmazonLinux2023},
+			instanceClass: ec2.InstanceClass_T4G,
+			parameter:     "/aws/service/ecs/optimized-ami/amazon-linux-2023/arm64/recommended/image_id",
+		},
+		{
+			name:          "bottlerocket",
+			image:         ContainerComputeMachineImageProps{Family: MachineImageBottlerocket},
+			instanceClass: ec2.InstanceClass_T3,
+			parameter:     "/aws/service/bottlerocket/aws-ecs-1/x86_64/latest/image_id",
+		},
+		{
+			name:          "custom parameter",
+			image:         ContainerComputeMachineImageProps{Family: MachineImageCustom, SsmParameter: "/golden/ecs/image_id"},
+			instanceClass: ec2.InstanceClass_T3,
+			parameter:     "/golden/ecs/image_id",
+		},
+		{
+			name:          "custom ami",
+			image:         ContainerComputeMachineImageProps{Family: MachineImageCustom, AmiId: "ami-0123456789abcdef0"},
+			instanceClass: ec2.InstanceClass_T3,
+			imageId:       "ami-0123456789abcdef0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			asg := &props.AsgCapacityProviders[0].AutoScalingGroup
+			asg.InstanceClass = tt.instanceClass
+			asg.MachineImage = tt.image
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			if tt.imageId != "" {
+				template.HasResourceProperties(jsii.String("AWS::EC2::LaunchTemplate"), map[string]interface{}{
+					"LaunchTemplateData": assertions.Match_ObjectLike(&map[string]interface{}{"ImageId": tt.imageId}),
+				})
+				return
+			}
+			template.HasParameter(jsii.String("*"), map[string]interface{}{
+				"Type":    "AWS::SSM::Parameter::Value<AWS::EC2::Image::Id>",
+				"Default": tt.parameter,
+			})
+		})
+	}
+}
+
+func TestContainerComputeMixedInstances(t *testing.T) {
+	tests := []struct {
+		name              string
+		mixed             ContainerComputeMixedInstancesProps
+		onDemandPercent   float64
+		allocation        string
+		capacityRebalance interface{}
+	}{
+		{
+			name: "spot",
+			mixed: ContainerComputeMixedInstancesProps{
+				Enabled:              true,
+				InstanceTypes:        []ContainerComputeInstanceType{{InstanceClass: ec2.InstanceClass_T3A, InstanceSize: ec2.InstanceSize_MICRO}},
+				OnDemandBaseCapacity: 1,
+				SpotPercentage:       70,
+			},
+			onDemandPercent:   30,
+			allocation:        "price-capacity-optimized",
+			capacityRebalance: true,
+		},
+		{
+			name: "on-demand",
+			mixed: ContainerComputeMixedInstancesProps{
+				Enabled:                true,
+				InstanceTypes:          []ContainerComputeInstanceType{{InstanceClass: ec2.InstanceClass_T3A, InstanceSize: ec2.InstanceSize_MICRO}},
+				SpotAllocationStrategy: autoscaling.SpotAllocationStrategy_CAPACITY_OPTIMIZED,
+			},
+			onDemandPercent:   100,
+			allocation:        "capacity-optimized",
+			capacityRebalance: assertions.Match_Absent(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			props.AsgCapacityProviders[0].AutoScalingGroup.MixedInstances = tt.mixed
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::AutoScaling::AutoScalingGroup"), map[string]interface{}{
+				"CapacityRebalance": tt.capacityRebalance,
+				"MixedInstancesPolicy": map[string]interface{}{
+					"InstancesDistribution": map[string]interface{}{
+						"OnDemandBaseCapacity":                tt.mixed.OnDemandBaseCapacity,
+						"OnDemandPercentageAboveBaseCapacity": tt.onDemandPercent,
+						"SpotAllocationStrategy":              tt.allocation,
+					},
+					"LaunchTemplate": map[string]interface{}{
+						"LaunchTemplateSpecification": assertions.Match_ObjectLike(&map[string]interface{}{
+							"LaunchTemplateId": assertions.Match_AnyValue(),
+						}),
+						"Overrides": []interface{}{
+							map[string]interface{}{"InstanceType": "t3.micro"},
+							map[string]interface{}{"InstanceType": "t3a.micro"},
+						},
+					},
+				},
+			})
+			template.ResourceCountIs(jsii.String("AWS::AutoScaling::LaunchConfiguration"), jsii.Number(0))
+		})
+	}
+}
+
+func TestContainerComputeLaunchTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		asg      func(props *ContainerComputeAsgProps)
+		metadata map[string]interface{}
+		device   map[string]interface{}
+	}{
+		{
+			name:     "defaults",
+			asg:      func(props *ContainerComputeAsgProps) {},
+			metadata: map[string]interface{}{"HttpEndpoint": "enabled", "HttpTokens": "required", "HttpPutResponseHopLimit": 2},
+			device: map[string]interface{}{
+				"DeviceName": "/dev/xvda",
+				"Ebs":        map[string]interface{}{"DeleteOnTermination": true, "Encrypted": true, "VolumeSize": 30, "VolumeType": "gp3"},
+			},
+		},
+		{
+			name: "bottlerocket with settings",
+			asg: func(props *ContainerComputeAsgProps) {
+				props.MachineImage.Family = MachineImageBottlerocket
+				props.AllowImdsv1 = true
+				props.MetadataHopLimit = 1
+				props.RootVolume = ContainerComputeRootVolumeProps{SizeGiB: 100, VolumeType: ec2.EbsDeviceVolumeType_IO2, Iops: 3000}
+			},
+			metadata: map[string]interface{}{"HttpEndpoint": "enabled", "HttpTokens": "optional", "HttpPutResponseHopLimit": 1},
+			device: map[string]interface{}{
+				"DeviceName": "/dev/xvdb",
+				"Ebs":        map[string]interface{}{"DeleteOnTermination": true, "Encrypted": true, "VolumeSize": 100, "VolumeType": "io2", "Iops": 3000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			tt.asg(&props.AsgCapacityProviders[0].AutoScalingGroup)
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::EC2::LaunchTemplate"), map[string]interface{}{
+				"LaunchTemplateName": "Asg",
+				"LaunchTemplateData": assertions.Match_ObjectLike(&map[string]interface{}{
+					"MetadataOptions":     tt.metadata,
+					"BlockDeviceMappings": []interface{}{tt.device},
+				}),
+			})
+			template.HasResourceProperties(jsii.String("AWS::AutoScaling::AutoScalingGroup"), map[string]interface{}{
+				"LaunchTemplate": assertions.Match_ObjectLike(&map[string]interface{}{
+					"LaunchTemplateId": assertions.Match_AnyValue(),
+				}),
+			})
+			template.ResourceCountIs(jsii.String("AWS::AutoScaling::LaunchConfiguration"), jsii.Number(0))
+		})
+	}
+}
+
+func TestContainerComputeManagedScaling(t *testing.T) {
+	tests := []struct {
+		name               string
+		capacityProvider   ContainerComputeAsgCapacityProviderProps
+		managedScaling     map[string]interface{}
+		terminationProtect string
+		protectedFromScale interface{}
+	}{
+		{
+			name:               "defaults",
+			capacityProvider:   ContainerComputeAsgCapacityProviderProps{Name: "AsgCapacityProvider"},
+			managedScaling:     map[string]interface{}{"Status": "ENABLED", "TargetCapacity": 100},
+			terminationProtect: "DISABLED",
+			protectedFromScale: assertions.Match_Absent(),
+		},
+		{
+			name: "tuned with termination protection",
+			capacityProvider: ContainerComputeAsgCapacityProviderProps{
+				Name:                               "AsgCapacityProvider",
+				TargetCapacityPercent:              90,
+				MinimumScalingStepSize:             1,
+				MaximumScalingStepSize:             5,
+				InstanceWarmupSeconds:              120,
+				EnableManagedTerminationProtection: true,
+			},
+			managedScaling: map[string]interface{}{
+				"Status":                 "ENABLED",
+				"TargetCapacity":         90,
+				"MinimumScalingStepSize": 1,
+				"MaximumScalingStepSize": 5,
+				"InstanceWarmupPeriod":   120,
+			},
+			terminationProtect: "ENABLED",
+			protectedFromScale: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			props.AsgCapacityProviders[0].CapacityProvider = tt.capacityProvider
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::ECS::CapacityProvider"), map[string]interface{}{
+				"Name": "AsgCapacityProvider",
+				"AutoScalingGroupProvider": assertions.Match_ObjectLike(&map[string]interface{}{
+					"ManagedScaling":               tt.managedScaling,
+					"ManagedTerminationProtection": tt.terminationProtect,
+				}),
+			})
+			template.HasResourceProperties(jsii.String("AWS::AutoScaling::AutoScalingGroup"), map[string]interface{}{
+				"NewInstancesProtectedFromScaleIn": tt.protectedFromScale,
+			})
+		})
+	}
+}
+
+func TestContainerComputeDefaultAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultAction ContainerComputeDefaultActionProps
+		want          map[string]interface{}
+	}{
+		{
+			name: "defaults",
+			want: map[string]interface{}{
+				"Type": "fixed-response",
+				"FixedResponseConfig": map[string]interface{}{
+					"StatusCode":  "404",
+					"ContentType": "text/plain",
+				},
+			},
+		},
+		{
+			name:          "fixed response",
+			defaultAction: ContainerComputeDefaultActionProps{StatusCode: 503, ContentType: "application/json", MessageBody: `{"status":"unavailable"}`},
+			want: map[string]interface{}{
+				"Type": "fixed-response",
+				"FixedResponseConfig": map[string]interface{}{
+					"StatusCode":  "503",
+					"ContentType": "application/json",
+					"MessageBody": `{"status":"unavailable"}`,
+				},
+			},
+		},
+		{
+			name:          "redirect",
+			defaultAction: ContainerComputeDefaultActionProps{Type: DefaultActionRedirect, RedirectHost: "www.example.com", Permanent: true},
+			want: map[string]interface{}{
+				"Type": "redirect",
+				"RedirectConfig": map[string]interface{}{
+					"Host":       "www.example.com",
+					"Path":       "/#{path}",
+					"Port":       "#{port}",
+					"Protocol":   "#{protocol}",
+					"Query":      "#{query}",
+					"StatusCode": "HTTP_301",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			props.LoadBalancer.DefaultAction = tt.defaultAction
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+				"Port":           443,
+				"Protocol":       "HTTPS",
+				"DefaultActions": []interface{}{tt.want},
+			})
+		})
+	}
+}
+
+func TestContainerComputeCertificates(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	sniCertificateArn := "arn:aws:acm:us-east-1:123456789012:certificate/7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	props.LoadBalancer.CertificateArns = []string{sniCertificateArn}
+	props.LoadBalancer.HostedZone = ContainerComputeHostedZoneProps{Id: "Z0123456789ABCDEFGHIJ", Name: "example.com"}
+	props.LoadBalancer.CertificateDomainNames = []string{"api.example.com"}
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+		"Certificates": []interface{}{map[string]interface{}{"CertificateArn": testCertificateArn}},
+	})
+	template.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::ListenerCertificate"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::ListenerCertificate"), map[string]interface{}{
+		"Certificates": []interface{}{map[string]interface{}{"CertificateArn": sniCertificateArn}},
+	})
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::ListenerCertificate"), map[string]interface{}{
+		"Certificates": []interface{}{
+			map[string]interface{}{"CertificateArn": map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("Certificateapi"))}},
+		},
+	})
+	template.HasResourceProperties(jsii.String("AWS::CertificateManager::Certificate"), map[string]interface{}{
+		"DomainName":       "api.example.com",
+		"ValidationMethod": "DNS",
+		"DomainValidationOptions": []interface{}{
+			map[string]interface{}{"DomainName": "api.example.com", "HostedZoneId": "Z0123456789ABCDEFGHIJ"},
+		},
+	})
+}
+
+func TestContainerComputeLoadBalancerHardening(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          string
+		loadBalancer string
+		sslPolicy    string
+		attributes   map[string]string
+	}{
+		{
+			name:      "dev preset",
+			env:       "dev",
+			sslPolicy: "ELBSecurityPolicy-TLS13-1-2-2021-06",
+			attributes: map[string]string{
+				"routing.http.drop_invalid_header_fields.enabled": "true",
+				"routing.http.desync_mitigation_mode":             "defensive",
+				"idle_timeout.timeout_seconds":                    "120",
+			},
+		},
+		{
+			name:      "prod preset",
+			env:       "prod",
+			sslPolicy: "ELBSecurityPolicy-TLS13-1-2-Res-2021-06",
+			attributes: map[string]string{
+				"deletion_protection.enabled":                     "true",
+				"routing.http.drop_invalid_header_fields.enabled": "true",
+				"routing.http.desync_mitigation_mode":             "defensive",
+			},
+		},
+		{
+			name:         "staging overrides",
+			env:          "staging",
+			loadBalancer: "    hardening:\n      sslPolicy: tls12-ext\n      http2: false\n      desyncMitigationMode: strictest\n      idleTimeoutSeconds: 300",
+			sslPolicy:    "ELBSecurityPolicy-TLS-1-2-Ext-2018-06",
+			attributes: map[string]string{
+				"routing.http2.enabled":               "false",
+				"routing.http.desync_mitigation_mode": "strictest",
+				"idle_timeout.timeout_seconds":        "300",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := synthConfigCompute(t, tt.env, tt.loadBalancer)
+
+			for key, value := range tt.attributes {
+				template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+					"LoadBalancerAttributes": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Key": key, "Value": value},
+					}),
+				})
+			}
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+				"Protocol":  "HTTPS",
+				"SslPolicy": tt.sslPolicy,
+			})
+		})
+	}
+}
+
+func TestContainerComputeAmiIdWithoutRegion(t *testing.T) {
+	// GIVEN
+	stack := awscdk.NewStack(awscdk.NewApp(nil), jsii.String("TestStack"), nil)
+	props := testComputeProps()
+	props.AsgCapacityProviders[0].AutoScalingGroup.MachineImage = ContainerComputeMachineImageProps{Family: MachineImageCustom, AmiId: "ami-0123456789abcdef0"}
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	want := "MachineImage.AmiId needs a stack with an explicit region, use MachineImage.SsmParameter otherwise"
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
+
+func TestContainerComputeForwardTargetNotAttached(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.LoadBalancer.DefaultAction = ContainerComputeDefaultActionProps{Type: DefaultActionForward, ServiceName: "Nginx"}
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &props)
+	// A listener rule under the service's name does not attach the service.
+	compute.ListenerRulePriority("Nginx", 0)
+
+	// THEN
+	want := `LoadBalancer.DefaultAction.ServiceName "Nginx" is not a service on this compute`
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
@@ -0,0 +1,325 @@
+package breezeware
+
+import (
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	ecs "github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	elbv2 "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	route53 "github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	route53targets "github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	servicediscovery "github.com/aws/aws-cdk-go/awscdk/v2/awsservicediscovery"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type ContainerService interface {
+	constructs.Construct
+	Service() ecs.Ec2Service
+	TaskDefinition() ecs.TaskDefinition
+	Container() ecs.ContainerDefinition
+	TargetGroup() elbv2.IApplicationTargetGroup
+}
+
+type containerService struct {
+	constructs.Construct
+	service        ecs.Ec2Service
+	taskDefinition ecs.TaskDefinition
+	container      ecs.ContainerDefinition
+	targetGroup    elbv2.IApplicationTargetGroup
+}
+
+type ContainerServiceTaskDefinitionProps struct {
+	Family string
+}
+
+// ContainerServiceContainerProps describes the service's container. Without a
+// LogGroupName the logs go to /ecs/<Name>, and LogStreamPrefix defaults to
+// Name, the same as for services loaded from config files.
+type ContainerServiceContainerProps struct {
+	Name             string
+	Image            string
+	Cpu              float64
+	MemoryLimitMiB   float64
+	ContainerPort    float64
+	Environment      map[string]string
+	LogGroupName     string
+	LogRetention     awslogs.RetentionDays
+	LogRemovalPolicy awscdk.RemovalPolicy
+	LogStreamPrefix  string
+}
+
+func (p *ContainerServiceContainerProps) logGroupName() string {
+	return defaultString(p.LogGroupName, "/ecs/"+p.Name)
+}
+
+func (p *ContainerServiceContainerProps) logStreamPrefix() string {
+	return defaultString(p.LogStreamPrefix, p.Name)
+}
+
+type ContainerServiceTargetGroupProps struct {
+	Name             string
+	HealthCheckPath  string
+	HealthyHttpCodes string
+}
+
+// ContainerServiceListenerRuleProps routes requests to the service. Priority
+// is optional; when it is zero the compute assigns one.
+type ContainerServiceListenerRuleProps struct {
+	Priority     float64
+	HostHeaders  []string
+	PathPatterns []string
+}
+
+type ContainerServiceCloudMapProps struct {
+	Name   string
+	DnsTtl float64
+}
+
+type ContainerServiceProps struct {
+	Name                       string
+	DesiredCount               float64
+	TaskDefinition             ContainerServiceTaskDefinitionProps
+	Container                  ContainerServiceContainerProps
+	CapacityProviderStrategies []CapacityProviderStrategyProps
+	TargetGroup                ContainerServiceTargetGroupProps
+	ListenerRule               ContainerServiceListenerRuleProps
+	CloudMap                   ContainerServiceCloudMapProps
+	Volumes                    []DockerVolumeProps
+	// AvailabilityZone pins tasks to one zone. Services with EBS volumes
+	// must set it because a volume cannot follow a task to another zone.
+	AvailabilityZone string
+	// IdleTimeoutSeconds is how long the service's WebSocket or other long
+	// lived connections stay idle. The compute's load balancer must allow at
+	// least that much.
+	IdleTimeoutSeconds float64
+}
+
+func NewContainerService(scope constructs.Construct, id *string, compute ContainerCompute, props *ContainerServiceProps) ContainerService {
+
+	this := constructs.NewConstruct(scope, id)
+
+	this.Node().AddValidation(&propsValidation{validate: props.validate})
+	this.Node().AddValidation(&propsValidation{validate: func() []string { return props.validateCompute(compute) }})
+
+	taskDefinition := createTaskDefinition(this, jsii.String("TaskDefinition"), &props.TaskDefinition)
+
+	container := createContainerDefinition(this, jsii.String("ContainerDefinition"), &props.Container, taskDefinition)
+
+	for i := range props.Volumes {
+		AddDockerVolume(taskDefinition, container, &props.Volumes[i])
+	}
+
+	service := createEc2Service(this, jsii.String("EcsService"), props, compute, taskDefinition)
+
+	target := service.LoadBalancerTarget(&ecs.LoadBalancerTargetOptions{
+		ContainerName: container.ContainerName(),
+		ContainerPort: container.ContainerPort(),
+		Protocol:      ecs.Protocol_TCP,
+	})
+	targetGroup := compute.AttachService(props.Name, target)
+	if targetGroup == nil {
+		targetGroup = createServiceTargetGroup(this, jsii.String("TargetGroup"), &props.TargetGroup, compute.Vpc(), target)
+	}
+
+	priority := compute.ListenerRulePriority(props.Name, props.ListenerRule.Priority)
+	createServiceListenerRule(this, jsii.String("ListenerRule"), &props.ListenerRule, priority, compute.HttpsListener(), targetGroup)
+
+	// The records belong to the service, so they are deleted together with
+	// it. Hosts the compute already has records for are left out.
+	if compute.HostedZone() != nil {
+		createAliasRecords(this, jsii.String("AliasRecord"), props.ListenerRule.HostHeaders, compute.HostedZone(), compute.LoadBalancer(), compute.AliasRecordNames())
+	}
+
+	return &containerService{this, service, taskDefinition, container, targetGroup}
+}
+
+func (s *containerService) Service() ecs.Ec2Service {
+	return s.service
+}
+
+func (td *containerService) TaskDefinition() ecs.TaskDefinition {
+	return td.taskDefinition
+}
+
+func (c *containerService) Container() ecs.ContainerDefinition {
+	return c.container
+}
+
+func (tg *containerService) TargetGroup() elbv2.IApplicationTargetGroup {
+	return tg.targetGroup
+}
+
+func createTaskDefinition(scope constructs.Construct, id *string, props *ContainerServiceTaskDefinitionProps) ecs.TaskDefinition {
+	taskDefinition := ecs.NewTaskDefinition(scope, id, &ecs.TaskDefinitionProps{
+		Family:        jsii.String(props.Family),
+		NetworkMode:   ecs.NetworkMode_AWS_VPC,
+		Compatibility: ecs.Compatibility_EC2,
+	})
+	return taskDefinition
+}
+
+func createContainerDefinition(scope constructs.Construct, id *string, props *ContainerServiceContainerProps, taskDefinition ecs.TaskDefinition) ecs.ContainerDefinition {
+	environment := make(map[string]*string)
+	for name, value := range props.Environment {
+		environment[name] = jsii.String(value)
+	}
+
+	retention := props.LogRetention
+	if retention == "" {
+		retention = awslogs.RetentionDays_ONE_WEEK
+	}
+	removalPolicy := props.LogRemovalPolicy
+	if removalPolicy == "" {
+		removalPolicy = awscdk.RemovalPolicy_DESTROY
+	}
+
+	container := ecs.NewContainerDefinition(scope, id, &ecs.ContainerDefinitionProps{
+		Image:         ecs.ContainerImage_FromRegistry(jsii.String(props.Image), &ecs.RepositoryImageProps{}),
+		ContainerName: jsii.String(props.Name),
+		Essential:     jsii.Bool(true),
+		PortMappings: &[]*ecs.PortMapping{{
+			ContainerPort: jsii.Number(props.ContainerPort),
+			Protocol:      ecs.Protocol_TCP,
+		}},
+		Cpu:            jsii.Number(props.Cpu),
+		MemoryLimitMiB: jsii.Number(props.MemoryLimitMiB),
+		Environment:    &environment,
+		Logging: ecs.AwsLogDriver_AwsLogs(&ecs.AwsLogDriverProps{
+			LogGroup: awslogs.NewLogGroup(scope, jsii.String("LogGroup"), &awslogs.LogGroupProps{
+				LogGroupName:  jsii.String(props.logGroupName()),
+				RemovalPolicy: removalPolicy,
+				Retention:     retention,
+			}),
+			StreamPrefix: jsii.String(props.logStreamPrefix()),
+		}),
+		TaskDefinition: taskDefinition,
+	})
+	return container
+}
+
+func createEc2Service(scope constructs.Construct, id *string, props *ContainerServiceProps, compute ContainerCompute, taskDefinition ecs.TaskDefinition) ecs.Ec2Service {
+	var strategies []*ecs.CapacityProviderStrategy
+	for _, strategy := range props.CapacityProviderStrategies {
+		capacityProvider := jsii.String(strategy.CapacityProvider)
+		if asgCapacityProvider, ok := compute.CapacityProviders()[strategy.CapacityProvider]; ok {
+			capacityProvider = asgCapacityProvider.CapacityProviderName()
+		}
+		strategies = append(strategies, &ecs.CapacityProviderStrategy{
+			CapacityProvider: capacityProvider,
+			Base:             jsii.Number(strategy.Base),
+			Weight:           jsii.Number(strategy.Weight),
+		})
+	}
+
+	dnsTtl := props.CloudMap.DnsTtl
+	if dnsTtl == 0 {
+		dnsTtl = 60
+	}
+
+	var placementConstraints []ecs.PlacementConstraint
+	if props.AvailabilityZone != "" {
+		placementConstraints = append(placementConstraints, ecs.PlacementConstraint_MemberOf(jsii.String("attribute:ecs.availability-zone == "+props.AvailabilityZone)))
+	}
+
+	serviceProps := &ecs.Ec2ServiceProps{
+		Cluster:                    compute.Cluster(),
+		CircuitBreaker:             &ecs.DeploymentCircuitBreaker{Rollback: jsii.Bool(true)},
+		TaskDefinition:             taskDefinition,
+		DesiredCount:               jsii.Number(props.DesiredCount),
+		ServiceName:                jsii.String(props.Name),
+		CapacityProviderStrategies: &strategies,
+		CloudMapOptions: &ecs.CloudMapOptions{
+			CloudMapNamespace: compute.CloudMapNamespace(),
+			Name:              jsii.String(props.CloudMap.Name),
+			DnsRecordType:     servicediscovery.DnsRecordType_A,
+			ContainerPort:     jsii.Number(props.Container.ContainerPort),
+			DnsTtl:            awscdk.Duration_Seconds(jsii.Number(dnsTtl)),
+		},
+		PlacementConstraints: &placementConstraints,
+	}
+	// An EBS volume attaches to one host at a time, so the old task has to
+	// stop before its replacement starts.
+	if props.hasZonalVolumes() {
+		serviceProps.MinHealthyPercent = jsii.Number(0)
+		serviceProps.MaxHealthyPercent = jsii.Number(100)
+	}
+
+	service := ecs.NewEc2Service(scope, id, serviceProps)
+	return service
+}
+
+func (p *ContainerServiceProps) hasZonalVolumes() bool {
+	for i := range p.Volumes {
+		if p.Volumes[i].zonal() {
+			return true
+		}
+	}
+	return false
+}
+
+func createServiceTargetGroup(scope constructs.Construct, id *string, props *ContainerServiceTargetGroupProps, vpc ec2.IVpc, target elbv2.IApplicationLoadBalancerTarget) elbv2.IApplicationTargetGroup {
+	tg := elbv2.NewApplicationTargetGroup(scope, id, &elbv2.ApplicationTargetGroupProps{
+		TargetGroupName: jsii.String(props.Name),
+		HealthCheck:     targetGroupHealthCheck(props.HealthCheckPath, props.HealthyHttpCodes),
+		TargetType:      elbv2.TargetType_IP,
+		Vpc:             vpc,
+		Protocol:        elbv2.ApplicationProtocol_HTTP,
+		Targets:         &[]elbv2.IApplicationLoadBalancerTarget{target},
+	})
+	return tg
+}
+
+func createServiceListenerRule(scope constructs.Construct, id *string, props *ContainerServiceListenerRuleProps, priority float64, listener elbv2.IApplicationListener, tg elbv2.IApplicationTargetGroup) elbv2.ApplicationListenerRule {
+	var conditions []elbv2.ListenerCondition
+	if len(props.HostHeaders) > 0 {
+		conditions = append(conditions, elbv2.ListenerCondition_HostHeaders(jsii.Strings(props.HostHeaders...)))
+	}
+	if len(props.PathPatterns) > 0 {
+		conditions = append(conditions, elbv2.ListenerCondition_PathPatterns(jsii.Strings(props.PathPatterns...)))
+	} else {
+		conditions = append(conditions, elbv2.ListenerCondition_PathPatterns(jsii.Strings("/*")))
+	}
+
+	rule := elbv2.NewApplicationListenerRule(scope, id, &elbv2.ApplicationListenerRuleProps{
+		Priority:   jsii.Number(priority),
+		Action:     elbv2.ListenerAction_Forward(&[]elbv2.IApplicationTargetGroup{tg}, &elbv2.ForwardOptions{}),
+		Conditions: &conditions,
+		Listener:   listener,
+	})
+	return rule
+}
+
+// createAliasRecords points every host header in zone at the load balancer
+// and returns the record names. The load balancer is IPv4 only, so there are
+// no AAAA records. Hosts in existing already have a record. Wildcards other
+// than a leading "*." cannot be records.
+func createAliasRecords(scope constructs.Construct, id *string, hostHeaders []string, zone route53.IHostedZone, lb elbv2.IApplicationLoadBalancer, existing []string) []string {
+	zoneName := strings.TrimSuffix(*zone.ZoneName(), ".")
+	target := route53.RecordTarget_FromAlias(route53targets.NewLoadBalancerTarget(lb))
+
+	var names []string
+	for _, hostHeader := range hostHeaders {
+		host := strings.ToLower(hostHeader)
+		if containsString(existing, host) {
+			continue
+		}
+		if strings.Contains(host, "?") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			awscdk.Annotations_Of(scope).AddWarning(jsii.String("No DNS record for host header " + hostHeader + ", only a leading *. wildcard is supported"))
+			continue
+		}
+		if host != zoneName && !strings.HasSuffix(host, "."+zoneName) {
+			awscdk.Annotations_Of(scope).AddWarning(jsii.String("No DNS record for host header " + hostHeader + ", it is not in hosted zone " + zoneName))
+			continue
+		}
+		route53.NewARecord(scope, jsii.String(*id+host+"A"), &route53.ARecordProps{
+			Zone:       zone,
+			RecordName: jsii.String(host),
+			Target:     target,
+		})
+		names = append(names, host)
+	}
+	return names
+}
@@ -0,0 +1,459 @@
+package breezeware
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func testServiceProps() ContainerServiceProps {
+	return ContainerServiceProps{
+		Name:           "Nginx",
+		DesiredCount:   1,
+		TaskDefinition: ContainerServiceTaskDefinitionProps{Family: "nginx"},
+		Container: ContainerServiceContainerProps{
+			Name:            "nginx",
+			Image:           "nginx:latest",
+			MemoryLimitMiB:  512,
+			ContainerPort:   80,
+			LogGroupName:    "/test/nginx",
+			LogStreamPrefix: "nginx",
+		},
+		CapacityProviderStrategies: []CapacityProviderStrategyProps{
+			{CapacityProvider: "AsgCapacityProvider", Weight: 1},
+		},
+		TargetGroup:  ContainerServiceTargetGroupProps{Name: "Nginx"},
+		ListenerRule: ContainerServiceListenerRuleProps{Priority: 1, HostHeaders: []string{"nginx.example.com"}},
+		CloudMap:     ContainerServiceCloudMapProps{Name: "nginx"},
+	}
+}
+
+// synthService adds props as a service on the test compute and returns the
+// stack's template.
+func synthService(props *ContainerServiceProps) assertions.Template {
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	NewContainerService(stack, jsii.String("Service"), compute, props)
+	return assertions.Template_FromStack(stack, nil)
+}
+
+func TestContainerService(t *testing.T) {
+	// GIVEN
+	props := testServiceProps()
+
+	// WHEN
+	template := synthService(&props)
+
+	// THEN
+	template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+		"Family":                  "nginx",
+		"NetworkMode":             "awsvpc",
+		"RequiresCompatibilities": []interface{}{"EC2"},
+		"ContainerDefinitions": []interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{
+				"Name":         "nginx",
+				"Image":        "nginx:latest",
+				"Essential":    true,
+				"Memory":       512,
+				"PortMappings": []interface{}{map[string]interface{}{"ContainerPort": 80, "Protocol": "tcp"}},
+				"LogConfiguration": assertions.Match_ObjectLike(&map[string]interface{}{
+					"LogDriver": "awslogs",
+					"Options": assertions.Match_ObjectLike(&map[string]interface{}{
+						"awslogs-stream-prefix": "nginx",
+					}),
+				}),
+			}),
+		},
+	})
+	template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
+		"LogGroupName":    "/test/nginx",
+		"RetentionInDays": 7,
+	})
+	template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+		"ServiceName":  "Nginx",
+		"DesiredCount": 1,
+		"CapacityProviderStrategy": []interface{}{
+			map[string]interface{}{
+				"CapacityProvider": map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("AsgCapacityProvider"))},
+				"Base":             0,
+				"Weight":           1,
+			},
+		},
+		"DeploymentConfiguration": assertions.Match_ObjectLike(&map[string]interface{}{
+			"DeploymentCircuitBreaker": map[string]interface{}{"Enable": true, "Rollback": true},
+		}),
+		"ServiceRegistries": assertions.Match_AnyValue(),
+	})
+	template.HasResourceProperties(jsii.String("AWS::ServiceDiscovery::Service"), map[string]interface{}{
+		"Name": "nginx",
+		"DnsConfig": assertions.Match_ObjectLike(&map[string]interface{}{
+			"DnsRecords": []interface{}{map[string]interface{}{"TTL": 60, "Type": "A"}},
+		}),
+	})
+}
+
+func TestContainerServiceLoadBalancing(t *testing.T) {
+	tests := []struct {
+		name         string
+		targetGroup  ContainerServiceTargetGroupProps
+		listenerRule ContainerServiceListenerRuleProps
+		healthCheck  map[string]interface{}
+		conditions   []interface{}
+	}{
+		{
+			name:         "defaults",
+			targetGroup:  ContainerServiceTargetGroupProps{Name: "Nginx"},
+			listenerRule: ContainerServiceListenerRuleProps{Priority: 1, HostHeaders: []string{"nginx.example.com"}},
+			healthCheck: map[string]interface{}{
+				"HealthCheckEnabled":         true,
+				"HealthCheckPath":            "/",
+				"HealthCheckIntervalSeconds": 30,
+				"Matcher":                    map[string]interface{}{"HttpCode": "200"},
+			},
+			conditions: []interface{}{
+				map[string]interface{}{"Field": "host-header", "HostHeaderConfig": map[string]interface{}{"Values": []interface{}{"nginx.example.com"}}},
+				map[string]interface{}{"Field": "path-pattern", "PathPatternConfig": map[string]interface{}{"Values": []interface{}{"/*"}}},
+			},
+		},
+		{
+			name:         "health check and path patterns",
+			targetGroup:  ContainerServiceTargetGroupProps{Name: "Nginx", HealthCheckPath: "/health", HealthyHttpCodes: "200-299"},
+			listenerRule: ContainerServiceListenerRuleProps{Priority: 7, PathPatterns: []string{"/api/*"}},
+			healthCheck: map[string]interface{}{
+				"HealthCheckPath": "/health",
+				"Matcher":         map[string]interface{}{"HttpCode": "200-299"},
+			},
+			conditions: []interface{}{
+				map[string]interface{}{"Field": "path-pattern", "PathPatternConfig": map[string]interface{}{"Values": []interface{}{"/api/*"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := testServiceProps()
+			props.TargetGroup = tt.targetGroup
+			props.ListenerRule = tt.listenerRule
+
+			template := synthService(&props)
+
+			healthCheck := map[string]interface{}{
+				"Name":       "Nginx",
+				"TargetType": "ip",
+				"Protocol":   "HTTP",
+			}
+			for key, value := range tt.healthCheck {
+				healthCheck[key] = value
+			}
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::TargetGroup"), healthCheck)
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), map[string]interface{}{
+				"Priority":   tt.listenerRule.Priority,
+				"Conditions": tt.conditions,
+				"Actions": []interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{"Type": "forward"}),
+				},
+			})
+		})
+	}
+}
+
+func TestContainerServicePlacement(t *testing.T) {
+	tests := []struct {
+		name        string
+		zone        string
+		volumes     []DockerVolumeProps
+		constraints interface{}
+		deployment  map[string]interface{}
+	}{
+		{
+			name:        "no zone",
+			constraints: assertions.Match_Absent(),
+			deployment: map[string]interface{}{
+				"MinimumHealthyPercent": 50,
+				"MaximumPercent":        200,
+			},
+		},
+		{
+			name: "zone",
+			zone: "us-east-1b",
+			constraints: []interface{}{
+				map[string]interface{}{"Type": "memberOf", "Expression": "attribute:ecs.availability-zone == us-east-1b"},
+			},
+			deployment: map[string]interface{}{
+				"MinimumHealthyPercent": 50,
+				"MaximumPercent":        200,
+			},
+		},
+		{
+			name:    "ebs volume",
+			zone:    "us-east-1a",
+			volumes: []DockerVolumeProps{RexrayEbsVolume("data", "/data", 20, "gp3")},
+			constraints: []interface{}{
+				map[string]interface{}{"Type": "memberOf", "Expression": "attribute:ecs.availability-zone == us-east-1a"},
+			},
+			deployment: map[string]interface{}{
+				"MinimumHealthyPercent": 0,
+				"MaximumPercent":        100,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			computeProps := testComputeProps()
+			computeProps.AsgCapacityProviders[0].AutoScalingGroup.VolumePlugins = []DockerVolumePlugin{{Name: RexrayEbsVolumePlugin}}
+			compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+			props := testServiceProps()
+			props.AvailabilityZone = tt.zone
+			props.Volumes = tt.volumes
+
+			// WHEN
+			NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+				"PlacementConstraints":    tt.constraints,
+				"DeploymentConfiguration": assertions.Match_ObjectLike(&tt.deployment),
+			})
+		})
+	}
+}
+
+func TestContainerServiceAvailabilityZoneOutsideVpc(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.AvailabilityZone = "us-east-1f"
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	want := `AvailabilityZone "us-east-1f" is not one of the VPC's zones us-east-1a, us-east-1b`
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
+
+func TestContainerServiceDefaultTargetGroup(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	computeProps.LoadBalancer.DefaultAction = ContainerComputeDefaultActionProps{Type: DefaultActionForward, ServiceName: "Nginx", HealthCheckPath: "/health"}
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+
+	// WHEN
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+		"DefaultActions": []interface{}{
+			map[string]interface{}{
+				"Type":           "forward",
+				"TargetGroupArn": map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("DefaultTargetGroup"))},
+			},
+		},
+	})
+	template.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::TargetGroup"), jsii.Number(1))
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::TargetGroup"), map[string]interface{}{
+		"Name":            "Nginx",
+		"TargetType":      "ip",
+		"HealthCheckPath": "/health",
+	})
+	template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+		"LoadBalancers": []interface{}{
+			map[string]interface{}{
+				"ContainerName":  "nginx",
+				"ContainerPort":  80,
+				"TargetGroupArn": map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("DefaultTargetGroup"))},
+			},
+		},
+	})
+}
+
+func TestContainerServiceAliasRecords(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	computeProps.LoadBalancer.HostedZone = ContainerComputeHostedZoneProps{Id: "Z0123456789ABCDEFGHIJ", Name: "example.com"}
+	computeProps.LoadBalancer.CertificateDomainNames = []string{"www.example.com", "*.app.example.com"}
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.ListenerRule.HostHeaders = []string{"Nginx.example.com", "*.app.example.com", "nginx.example.org"}
+
+	// WHEN
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	if got, want := compute.AliasRecordNames(), []string{"www.example.com", "*.app.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AliasRecordNames() = %q, want %q", got, want)
+	}
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::Route53::RecordSet"), jsii.Number(3))
+	for _, name := range []string{"www.example.com.", "*.app.example.com.", "nginx.example.com."} {
+		template.HasResourceProperties(jsii.String("AWS::Route53::RecordSet"), map[string]interface{}{
+			"Name":         name,
+			"Type":         "A",
+			"HostedZoneId": "Z0123456789ABCDEFGHIJ",
+			"AliasTarget": assertions.Match_ObjectLike(&map[string]interface{}{
+				"DNSName": assertions.Match_AnyValue(),
+			}),
+		})
+	}
+	assertions.Annotations_FromStack(stack).HasWarning(jsii.String("/TestStack/Service"), assertions.Match_StringLikeRegexp(jsii.String("nginx.example.org, it is not in hosted zone example.com")))
+}
+
+func TestContainerServiceIdleTimeout(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	computeProps.LoadBalancer.Hardening.IdleTimeoutSeconds = 600
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.IdleTimeoutSeconds = 600
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	// THEN
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+		"LoadBalancerAttributes": assertions.Match_ArrayWith(&[]interface{}{
+			map[string]interface{}{"Key": "idle_timeout.timeout_seconds", "Value": "600"},
+		}),
+	})
+}
+
+func TestContainerServiceIdleTimeoutAboveCompute(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.IdleTimeoutSeconds = 600
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	want := "IdleTimeoutSeconds (600) is more than the compute's load balancer allows (120), raise LoadBalancer.Hardening.IdleTimeoutSeconds"
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
+
+func TestContainerServiceAvailabilityZoneOnFargate(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	computeProps.Cluster.IsFargateCapacityProviderEnabled = true
+	computeProps.Cluster.DefaultCapacityProviderStrategy = []CapacityProviderStrategyProps{{CapacityProvider: FargateCapacityProvider, Weight: 1}}
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.CapacityProviderStrategies = nil
+	props.AvailabilityZone = "us-east-1a"
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	want := "cluster DefaultCapacityProviderStrategy[0] FARGATE does not support AvailabilityZone"
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
+
+func TestContainerServiceDefaultTargetGroupErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		service func(props *ContainerServiceProps)
+		want    string
+	}{
+		{
+			name:    "no such service",
+			service: func(props *ContainerServiceProps) { props.Name = "Api" },
+			want:    `LoadBalancer.DefaultAction.ServiceName "Nginx" is not a service on this compute`,
+		},
+		{
+			name:    "own health check",
+			service: func(props *ContainerServiceProps) { props.TargetGroup.HealthCheckPath = "/health" },
+			want:    "TargetGroup.HealthCheckPath and HealthyHttpCodes would be ignored, set them on the compute's LoadBalancer.DefaultAction",
+		},
+		{
+			name:    "own target group name",
+			service: func(props *ContainerServiceProps) { props.TargetGroup.Name = "NginxTargetGroup" },
+			want:    `TargetGroup.Name "NginxTargetGroup" would be ignored, the service registers into the listener's default target group "Nginx"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			computeProps := testComputeProps()
+			computeProps.LoadBalancer.DefaultAction = ContainerComputeDefaultActionProps{Type: DefaultActionForward, ServiceName: "Nginx"}
+			compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+			props := testServiceProps()
+			tt.service(&props)
+			NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+			// THEN
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), tt.want) {
+					t.Errorf("Synth() panic = %v, want %s", r, tt.want)
+				}
+			}()
+
+			// WHEN
+			awscdk.Stage_Of(stack).Synth(nil)
+		})
+	}
+}
+
+func TestContainerServiceLogDefaults(t *testing.T) {
+	// GIVEN
+	props := testServiceProps()
+	props.Container.LogGroupName = ""
+	props.Container.LogStreamPrefix = ""
+
+	// WHEN
+	template := synthService(&props)
+
+	// THEN
+	template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
+		"LogGroupName": "/ecs/nginx",
+	})
+	template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+		"ContainerDefinitions": []interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{
+				"LogConfiguration": assertions.Match_ObjectLike(&map[string]interface{}{
+					"Options": assertions.Match_ObjectLike(&map[string]interface{}{
+						"awslogs-stream-prefix": "nginx",
+					}),
+				}),
+			}),
+		},
+	})
+}
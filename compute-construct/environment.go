@@ -0,0 +1,185 @@
+package breezeware
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	elbv2 "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type Environment struct {
+	Name          string
+	Account       string
+	Region        string
+	NamePrefix    string
+	Tags          map[string]string
+	RemovalPolicy awscdk.RemovalPolicy
+	// LoadBalancerHardening is the starting point for the compute's load
+	// balancer settings, which the config can override field by field.
+	LoadBalancerHardening ContainerComputeLoadBalancerHardeningProps
+}
+
+var (
+	// DefaultLoadBalancerHardening allows TLS 1.2 and 1.3 only, drops
+	// malformed headers and keeps desync mitigation defensive.
+	DefaultLoadBalancerHardening = ContainerComputeLoadBalancerHardeningProps{
+		SslPolicy:               elbv2.SslPolicy_RECOMMENDED_TLS,
+		DropInvalidHeaderFields: true,
+		DesyncMitigationMode:    elbv2.DesyncMitigationMode_DEFENSIVE,
+	}
+	// ProdLoadBalancerHardening also restricts TLS 1.2 to the strongest
+	// ciphers and protects the load balancer from deletion.
+	ProdLoadBalancerHardening = ContainerComputeLoadBalancerHardeningProps{
+		SslPolicy:               elbv2.SslPolicy_TLS13_RES,
+		DeletionProtection:      true,
+		DropInvalidHeaderFields: true,
+		DesyncMitigationMode:    elbv2.DesyncMitigationMode_DEFENSIVE,
+	}
+)
+
+var (
+	DevEnvironment = Environment{
+		Name:                  "dev",
+		NamePrefix:            "Dev",
+		RemovalPolicy:         awscdk.RemovalPolicy_DESTROY,
+		LoadBalancerHardening: DefaultLoadBalancerHardening,
+	}
+	StagingEnvironment = Environment{
+		Name:                  "staging",
+		NamePrefix:            "Stg",
+		RemovalPolicy:         awscdk.RemovalPolicy_DESTROY,
+		LoadBalancerHardening: DefaultLoadBalancerHardening,
+	}
+	ProdEnvironment = Environment{
+		Name:                  "prod",
+		NamePrefix:            "Prd",
+		RemovalPolicy:         awscdk.RemovalPolicy_RETAIN,
+		LoadBalancerHardening: ProdLoadBalancerHardening,
+	}
+)
+
+var environments = map[string]Environment{
+	DevEnvironment.Name:     DevEnvironment,
+	StagingEnvironment.Name: StagingEnvironment,
+	ProdEnvironment.Name:    ProdEnvironment,
+}
+
+var removalPolicies = map[string]awscdk.RemovalPolicy{
+	"destroy":  awscdk.RemovalPolicy_DESTROY,
+	"retain":   awscdk.RemovalPolicy_RETAIN,
+	"snapshot": awscdk.RemovalPolicy_SNAPSHOT,
+}
+
+// LookupEnvironment returns the preset for dev, staging or prod.
+func LookupEnvironment(name string) (Environment, error) {
+	env, ok := environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("unknown environment %q, expected one of %s", name, strings.Join(sortedKeys(environments), ", "))
+	}
+	env.Tags = map[string]string{"Environment": env.Name}
+	return env, nil
+}
+
+// SelectedEnvironments reads the "env" context key, which holds a single
+// environment, a comma separated list or "all".
+func SelectedEnvironments(scope constructs.Construct) []string {
+	value, _ := scope.Node().TryGetContext(jsii.String(EnvironmentContextKey)).(string)
+	if value == "" {
+		return []string{DefaultEnvironment}
+	}
+	if value == "all" {
+		return []string{DevEnvironment.Name, StagingEnvironment.Name, ProdEnvironment.Name}
+	}
+
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (e *Environment) Env() *awscdk.Environment {
+	account := e.Account
+	if account == "" {
+		account = os.Getenv("CDK_DEFAULT_ACCOUNT")
+	}
+	region := e.Region
+	if region == "" {
+		region = os.Getenv("CDK_DEFAULT_REGION")
+	}
+	return &awscdk.Environment{
+		Account: jsii.String(account),
+		Region:  jsii.String(region),
+	}
+}
+
+func (e *Environment) PhysicalName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return e.NamePrefix + name
+}
+
+func (e *Environment) StackId(name string) string {
+	return e.NamePrefix + name
+}
+
+func (e *Environment) ApplyTags(scope constructs.Construct) {
+	for key, value := range e.Tags {
+		awscdk.Tags_Of(scope).Add(jsii.String(key), jsii.String(value), &awscdk.TagProps{})
+	}
+}
+
+// applyTo prefixes every physical name in the config so the same file layout
+// can be deployed side by side for several environments.
+func (e *Environment) applyTo(config *EnvironmentConfig) {
+	compute := &config.Compute
+	compute.Vpc.Name = e.PhysicalName(compute.Vpc.Name)
+	compute.Cluster.Name = e.PhysicalName(compute.Cluster.Name)
+	compute.LoadBalancer.Name = e.PhysicalName(compute.LoadBalancer.Name)
+	compute.LoadBalancer.DefaultAction.ServiceName = e.PhysicalName(compute.LoadBalancer.DefaultAction.ServiceName)
+	compute.SessionLogging.DocumentName = e.PhysicalName(compute.SessionLogging.DocumentName)
+	compute.SessionLogging.RemovalPolicy = e.RemovalPolicy
+	if accessLogs := &compute.LoadBalancer.AccessLogs; accessLogs.Enabled {
+		accessLogs.Prefix = strings.TrimSuffix(strings.ToLower(e.Name)+"/"+accessLogs.Prefix, "/")
+		accessLogs.RemovalPolicy = e.RemovalPolicy
+	}
+	if compute.CloudmapNamespace.Name != "" {
+		compute.CloudmapNamespace.Name = strings.ToLower(e.Name) + "." + compute.CloudmapNamespace.Name
+	}
+	for i := range compute.Cluster.DefaultCapacityProviderStrategy {
+		strategy := &compute.Cluster.DefaultCapacityProviderStrategy[i]
+		strategy.CapacityProvider = e.capacityProviderName(strategy.CapacityProvider)
+	}
+	for i := range compute.AsgCapacityProviders {
+		asgCapacityProvider := &compute.AsgCapacityProviders[i]
+		asgCapacityProvider.AutoScalingGroup.Name = e.PhysicalName(asgCapacityProvider.AutoScalingGroup.Name)
+		asgCapacityProvider.CapacityProvider.Name = e.PhysicalName(asgCapacityProvider.CapacityProvider.Name)
+	}
+
+	for i := range config.Services {
+		service := &config.Services[i]
+		service.Name = e.PhysicalName(service.Name)
+		service.TaskDefinition.Family = e.PhysicalName(service.TaskDefinition.Family)
+		service.TargetGroup.Name = e.PhysicalName(service.TargetGroup.Name)
+		service.Container.LogGroupName = "/" + strings.ToLower(e.Name) + service.Container.LogGroupName
+		service.Container.LogRemovalPolicy = e.RemovalPolicy
+		for j := range service.CapacityProviderStrategies {
+			strategy := &service.CapacityProviderStrategies[j]
+			strategy.CapacityProvider = e.capacityProviderName(strategy.CapacityProvider)
+		}
+	}
+}
+
+func (e *Environment) capacityProviderName(name string) string {
+	if isFargateCapacityProvider(name) {
+		return name
+	}
+	return e.PhysicalName(name)
+}
@@ -0,0 +1,206 @@
+package breezeware
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	iam "github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	kms "github.com/aws/aws-cdk-go/awscdk/v2/awskms"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	ssm "github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type InstanceAccessMode string
+
+const (
+	InstanceAccessSessionManager InstanceAccessMode = "SESSION_MANAGER"
+	InstanceAccessSsh            InstanceAccessMode = "SSH"
+	InstanceAccessNone           InstanceAccessMode = "NONE"
+)
+
+// InstanceAccessProps controls how operators reach an EC2 instance. Mode
+// defaults to Session Manager. SSH mode keeps Session Manager and adds the key
+// pair and port 22 rules, so SSH is opt-in only.
+type InstanceAccessProps struct {
+	Mode            InstanceAccessMode
+	SshKeyName      string
+	SshAllowedCidrs []string
+}
+
+func (p *InstanceAccessProps) sessionManagerEnabled() bool {
+	return p.Mode != InstanceAccessNone
+}
+
+// KeyName returns the key pair to launch with, which is nil unless Mode is SSH.
+func (p *InstanceAccessProps) KeyName() *string {
+	if p.Mode != InstanceAccessSsh || p.SshKeyName == "" {
+		return nil
+	}
+	return jsii.String(p.SshKeyName)
+}
+
+// ConfigureInstanceAccess attaches AmazonSSMManagedInstanceCore to role and, in
+// SSH mode, opens port 22 on securityGroup to SshAllowedCidrs.
+func ConfigureInstanceAccess(role iam.IRole, securityGroup ec2.ISecurityGroup, props *InstanceAccessProps) {
+	configureInstanceAccess(role, securityGroup, props, false)
+}
+
+// configureInstanceAccess leaves the Session Manager policy out when role
+// already gets it elsewhere, as Bottlerocket roles do from the ECS cluster.
+func configureInstanceAccess(role iam.IRole, securityGroup ec2.ISecurityGroup, props *InstanceAccessProps, ssmPolicyAttached bool) {
+	if props.sessionManagerEnabled() && !ssmPolicyAttached {
+		role.AddManagedPolicy(iam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AmazonSSMManagedInstanceCore")))
+	}
+	if props.Mode != InstanceAccessSsh {
+		return
+	}
+	for _, cidr := range props.SshAllowedCidrs {
+		securityGroup.AddIngressRule(
+			ec2.Peer_Ipv4(jsii.String(cidr)),
+			ec2.Port_Tcp(jsii.Number(22)),
+			jsii.String("SSH"),
+			jsii.Bool(false),
+		)
+	}
+}
+
+type SessionLogging interface {
+	constructs.Construct
+	Key() kms.IKey
+	Bucket() awss3.IBucket
+	LogGroup() awslogs.ILogGroup
+	DocumentName() *string
+	GrantWrite(grantee iam.IGrantable)
+}
+
+type sessionLogging struct {
+	constructs.Construct
+	key          kms.IKey
+	bucket       awss3.IBucket
+	logGroup     awslogs.ILogGroup
+	documentName *string
+}
+
+// SessionLoggingProps sends Session Manager transcripts to a KMS encrypted
+// bucket, log group or both. Sessions must be started with DocumentName to be
+// recorded.
+type SessionLoggingProps struct {
+	DocumentName    string
+	LogToS3         bool
+	LogToCloudWatch bool
+	LogGroupName    string
+	LogRetention    awslogs.RetentionDays
+	RemovalPolicy   awscdk.RemovalPolicy
+}
+
+func (p *SessionLoggingProps) enabled() bool {
+	return p.LogToS3 || p.LogToCloudWatch
+}
+
+func NewSessionLogging(scope constructs.Construct, id *string, props *SessionLoggingProps) SessionLogging {
+
+	this := constructs.NewConstruct(scope, id)
+
+	this.Node().AddValidation(&propsValidation{validate: props.validate})
+
+	removalPolicy := props.RemovalPolicy
+	if removalPolicy == "" {
+		removalPolicy = awscdk.RemovalPolicy_RETAIN
+	}
+
+	key := kms.NewKey(this, jsii.String("Key"), &kms.KeyProps{
+		Description:       jsii.String("Session Manager logs for " + props.DocumentName),
+		EnableKeyRotation: jsii.Bool(true),
+		RemovalPolicy:     removalPolicy,
+	})
+
+	inputs := map[string]interface{}{
+		"kmsKeyId":     key.KeyId(),
+		"runAsEnabled": false,
+	}
+
+	var bucket awss3.IBucket
+	if props.LogToS3 {
+		bucket = awss3.NewBucket(this, jsii.String("Bucket"), &awss3.BucketProps{
+			Encryption:        awss3.BucketEncryption_KMS,
+			EncryptionKey:     key,
+			BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+			EnforceSSL:        jsii.Bool(true),
+			RemovalPolicy:     removalPolicy,
+		})
+		inputs["s3BucketName"] = bucket.BucketName()
+		inputs["s3EncryptionEnabled"] = true
+	}
+
+	var logGroup awslogs.ILogGroup
+	if props.LogToCloudWatch {
+		logGroupName := props.LogGroupName
+		if logGroupName == "" {
+			logGroupName = "/ssm/sessions/" + props.DocumentName
+		}
+		retention := props.LogRetention
+		if retention == "" {
+			retention = awslogs.RetentionDays_ONE_YEAR
+		}
+		key.GrantEncryptDecrypt(iam.NewServicePrincipal(jsii.String("logs."+*awscdk.Aws_REGION()+".amazonaws.com"), &iam.ServicePrincipalOpts{}))
+		logGroup = awslogs.NewLogGroup(this, jsii.String("LogGroup"), &awslogs.LogGroupProps{
+			LogGroupName:  jsii.String(logGroupName),
+			EncryptionKey: key,
+			Retention:     retention,
+			RemovalPolicy: removalPolicy,
+		})
+		inputs["cloudWatchLogGroupName"] = logGroup.LogGroupName()
+		inputs["cloudWatchEncryptionEnabled"] = true
+		inputs["cloudWatchStreamingEnabled"] = true
+	}
+
+	document := ssm.NewCfnDocument(this, jsii.String("Document"), &ssm.CfnDocumentProps{
+		Name:         jsii.String(props.DocumentName),
+		DocumentType: jsii.String("Session"),
+		Content: map[string]interface{}{
+			"schemaVersion": "1.0",
+			"description":   "Session Manager preferences with encrypted session logs",
+			"sessionType":   "Standard_Stream",
+			"inputs":        inputs,
+		},
+	})
+
+	return &sessionLogging{this, key, bucket, logGroup, document.Ref()}
+}
+
+func (s *sessionLogging) Key() kms.IKey {
+	return s.key
+}
+
+func (s *sessionLogging) Bucket() awss3.IBucket {
+	return s.bucket
+}
+
+func (s *sessionLogging) LogGroup() awslogs.ILogGroup {
+	return s.logGroup
+}
+
+func (s *sessionLogging) DocumentName() *string {
+	return s.documentName
+}
+
+// GrantWrite lets an instance role decrypt session data and write transcripts.
+func (s *sessionLogging) GrantWrite(grantee iam.IGrantable) {
+	s.key.GrantEncryptDecrypt(grantee)
+	if s.bucket != nil {
+		s.bucket.GrantPut(grantee, nil)
+		grantee.GrantPrincipal().AddToPrincipalPolicy(iam.NewPolicyStatement(&iam.PolicyStatementProps{
+			Actions:   jsii.Strings("s3:GetEncryptionConfiguration"),
+			Resources: jsii.Strings(*s.bucket.BucketArn()),
+		}))
+	}
+	if s.logGroup != nil {
+		s.logGroup.GrantWrite(grantee)
+		grantee.GrantPrincipal().AddToPrincipalPolicy(iam.NewPolicyStatement(&iam.PolicyStatementProps{
+			Actions:   jsii.Strings("logs:DescribeLogGroups", "logs:DescribeLogStreams"),
+			Resources: jsii.Strings("*"),
+		}))
+	}
+}
@@ -0,0 +1,257 @@
+package breezeware
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	iam "github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/jsii-runtime-go"
+)
+
+var ssmManagedInstanceCore = map[string]interface{}{
+	"Fn::Join": []interface{}{"", []interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, ":iam::aws:policy/AmazonSSMManagedInstanceCore"}},
+}
+
+func TestInstanceAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		access     InstanceAccessProps
+		ssm        bool
+		keyName    interface{}
+		sshIngress bool
+	}{
+		{
+			name:    "session manager by default",
+			ssm:     true,
+			keyName: assertions.Match_Absent(),
+		},
+		{
+			name:       "ssh opt-in",
+			access:     InstanceAccessProps{Mode: InstanceAccessSsh, SshKeyName: "ops", SshAllowedCidrs: []string{"10.1.0.0/16"}},
+			ssm:        true,
+			keyName:    "ops",
+			sshIngress: true,
+		},
+		{
+			name:    "none",
+			access:  InstanceAccessProps{Mode: InstanceAccessNone},
+			keyName: assertions.Match_Absent(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			props.AsgCapacityProviders[0].AutoScalingGroup.Access = tt.access
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			managedPolicies := interface{}(assertions.Match_Absent())
+			if tt.ssm {
+				managedPolicies = assertions.Match_ArrayWith(&[]interface{}{ssmManagedInstanceCore})
+			}
+			template.HasResourceProperties(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+				"RoleName":          "AsgInstanceProfileRole",
+				"ManagedPolicyArns": managedPolicies,
+			})
+			template.HasResourceProperties(jsii.String("AWS::EC2::LaunchTemplate"), map[string]interface{}{
+				"LaunchTemplateData": assertions.Match_ObjectLike(&map[string]interface{}{"KeyName": tt.keyName}),
+			})
+			sshRule := assertions.Match_ObjectLike(&map[string]interface{}{"CidrIp": "10.1.0.0/16", "FromPort": 22, "ToPort": 22})
+			sshRules := template.FindResources(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+				"Properties": assertions.Match_ObjectLike(&map[string]interface{}{
+					"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{sshRule}),
+				}),
+			})
+			if got := len(*sshRules) > 0; got != tt.sshIngress {
+				t.Errorf("SSH ingress = %v, want %v", got, tt.sshIngress)
+			}
+		})
+	}
+}
+
+func TestSessionLogging(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.SessionLogging = SessionLoggingProps{LogToS3: true, LogToCloudWatch: true}
+
+	// WHEN
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::SSM::Document"), map[string]interface{}{
+		"Name":         "ClusterSessionManagerRunShell",
+		"DocumentType": "Session",
+		"Content": assertions.Match_ObjectLike(&map[string]interface{}{
+			"sessionType": "Standard_Stream",
+			"inputs": assertions.Match_ObjectLike(&map[string]interface{}{
+				"kmsKeyId":                    assertions.Match_AnyValue(),
+				"s3BucketName":                assertions.Match_AnyValue(),
+				"s3EncryptionEnabled":         true,
+				"cloudWatchLogGroupName":      assertions.Match_AnyValue(),
+				"cloudWatchEncryptionEnabled": true,
+				"cloudWatchStreamingEnabled":  true,
+			}),
+		}),
+	})
+	template.HasResourceProperties(jsii.String("AWS::KMS::Key"), map[string]interface{}{
+		"EnableKeyRotation": true,
+	})
+	template.HasResourceProperties(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+		"BucketEncryption": map[string]interface{}{
+			"ServerSideEncryptionConfiguration": []interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{
+					"ServerSideEncryptionByDefault": assertions.Match_ObjectLike(&map[string]interface{}{"SSEAlgorithm": "aws:kms"}),
+				}),
+			},
+		},
+	})
+	template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
+		"LogGroupName":    "/ssm/sessions/ClusterSessionManagerRunShell",
+		"RetentionInDays": 365,
+		"KmsKeyId":        assertions.Match_AnyValue(),
+	})
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"), map[string]interface{}{
+		"PolicyDocument": map[string]interface{}{
+			"Statement": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{"Action": "s3:GetEncryptionConfiguration"}),
+			}),
+			"Version": "2012-10-17",
+		},
+		"Roles": []interface{}{map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("IamRoleAsg"))}},
+	})
+	if compute.SessionLogging() == nil || compute.SessionLogging().Bucket() == nil || compute.SessionLogging().LogGroup() == nil {
+		t.Error("SessionLogging() does not expose the bucket and log group")
+	}
+}
+
+func TestContainerComputeInstanceRole(t *testing.T) {
+	extra := iam.PolicyDocument_FromJson(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{
+			map[string]interface{}{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::config/*"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		asg      func(props *ContainerComputeAsgProps)
+		policies interface{}
+	}{
+		{
+			name: "rexray plugin",
+			asg: func(props *ContainerComputeAsgProps) {
+				props.VolumePlugins = []DockerVolumePlugin{{Name: RexrayEbsVolumePlugin}}
+			},
+			policies: []interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{"PolicyName": "Ec2VolumeAccess"}),
+			},
+		},
+		{
+			name:     "no volume plugins",
+			asg:      func(props *ContainerComputeAsgProps) {},
+			policies: assertions.Match_Absent(),
+		},
+		{
+			name: "bottlerocket with an inline policy",
+			asg: func(props *ContainerComputeAsgProps) {
+				props.MachineImage.Family = MachineImageBottlerocket
+				props.Role.InlinePolicies = map[string]iam.PolicyDocument{"Config": extra}
+			},
+			policies: []interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{"PolicyName": "Config"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			props := testComputeProps()
+			tt.asg(&props.AsgCapacityProviders[0].AutoScalingGroup)
+
+			// WHEN
+			NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+			// THEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.HasResourceProperties(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+				"RoleName": "AsgInstanceProfileRole",
+				"Policies": tt.policies,
+			})
+		})
+	}
+}
+
+func TestContainerComputeInstanceRoleExtras(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.AsgCapacityProviders[0].AutoScalingGroup.VolumePlugins = []DockerVolumePlugin{{Name: RexrayEbsVolumePlugin}}
+	props.AsgCapacityProviders[0].AutoScalingGroup.Role = ContainerComputeAsgRoleProps{
+		ManagedPolicyArns:      []string{"arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy"},
+		PermissionsBoundaryArn: "arn:aws:iam::123456789012:policy/Boundary",
+	}
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+		"RoleName":            "AsgInstanceProfileRole",
+		"ManagedPolicyArns":   assertions.Match_ArrayWith(&[]interface{}{"arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy", ssmManagedInstanceCore}),
+		"PermissionsBoundary": "arn:aws:iam::123456789012:policy/Boundary",
+		"Policies": assertions.Match_ArrayWith(&[]interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{
+				"PolicyName": "Ec2VolumeAccess",
+				"PolicyDocument": assertions.Match_ObjectLike(&map[string]interface{}{
+					"Statement": assertions.Match_ArrayWith(&[]interface{}{
+						assertions.Match_ObjectLike(&map[string]interface{}{
+							"Action":    []interface{}{"ec2:AttachVolume", "ec2:DetachVolume"},
+							"Condition": map[string]interface{}{"StringEquals": map[string]interface{}{"aws:ResourceTag/aws:autoscaling:groupName": "Asg"}},
+						}),
+					}),
+				}),
+			}),
+		}),
+	})
+}
+
+func TestBottlerocketSessionManagerPolicy(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.AsgCapacityProviders[0].AutoScalingGroup.MachineImage.Family = MachineImageBottlerocket
+
+	// WHEN
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	roles := template.FindResources(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+		"Properties": map[string]interface{}{"RoleName": "AsgInstanceProfileRole"},
+	})
+	for _, role := range *roles {
+		policies, err := json.Marshal((*role)["Properties"].(map[string]interface{})["ManagedPolicyArns"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(policies), "AmazonSSMManagedInstanceCore"); got != 1 {
+			t.Errorf("ManagedPolicyArns = %s, want AmazonSSMManagedInstanceCore once", policies)
+		}
+	}
+	if len(*roles) != 1 {
+		t.Errorf("found %d instance roles, want 1", len(*roles))
+	}
+}
@@ -0,0 +1,70 @@
+package breezeware
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// listenerRulePriorities hands out HTTPS listener rule priorities. A key keeps
+// its explicit priority or gets one derived from its own hash, so adding,
+// renaming or overriding one service never moves another. Keys that land on
+// the same priority fail validation instead of being moved.
+type listenerRulePriorities struct {
+	priorities map[string]float64
+	explicit   map[string]bool
+	claims     map[string]int
+}
+
+func newListenerRulePriorities() *listenerRulePriorities {
+	return &listenerRulePriorities{
+		priorities: make(map[string]float64),
+		explicit:   make(map[string]bool),
+		claims:     make(map[string]int),
+	}
+}
+
+// claim registers key and returns its priority. A priority of zero asks for
+// one derived from key.
+func (p *listenerRulePriorities) claim(key string, priority float64) float64 {
+	p.claims[key]++
+	if priority > 0 {
+		p.explicit[key] = true
+	} else {
+		priority = hashedPriority(key)
+	}
+	p.priorities[key] = priority
+	return priority
+}
+
+func (p *listenerRulePriorities) validate() []string {
+	var errs []string
+	for _, key := range sortedKeys(p.claims) {
+		if p.claims[key] > 1 {
+			errs = append(errs, fmt.Sprintf("listener rule key %q is used by %d rules", key, p.claims[key]))
+		}
+	}
+
+	owners := make(map[float64][]string)
+	for _, key := range sortedKeys(p.priorities) {
+		owners[p.priorities[key]] = append(owners[p.priorities[key]], key)
+	}
+	var priorities []float64
+	for priority := range owners {
+		priorities = append(priorities, priority)
+	}
+	sort.Float64s(priorities)
+	for _, priority := range priorities {
+		if len(owners[priority]) > 1 {
+			errs = append(errs, fmt.Sprintf("listener rule priority %v is claimed by %s, set an explicit Priority so that they differ", priority, strings.Join(owners[priority], ", ")))
+		}
+	}
+	return errs
+}
+
+func hashedPriority(key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return float64(h.Sum32()%maxListenerRulePriority + 1)
+}
@@ -0,0 +1,122 @@
+package breezeware
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestListenerRulePriorities(t *testing.T) {
+	type claim struct {
+		key      string
+		priority float64
+	}
+
+	tests := []struct {
+		name   string
+		claims []claim
+		want   []float64
+		errs   []string
+	}{
+		{
+			name:   "explicit priorities",
+			claims: []claim{{"Api", 10}, {"Web", 20}},
+			want:   []float64{10, 20},
+		},
+		{
+			name:   "hashed priorities",
+			claims: []claim{{"DevNginxDemo", 0}, {"DevApi", 0}},
+			want:   []float64{hashedPriority("DevNginxDemo"), hashedPriority("DevApi")},
+		},
+		{
+			name:   "explicit priority collides with a hashed one",
+			claims: []claim{{"DevNginxDemo", 0}, {"DevApi", hashedPriority("DevNginxDemo")}},
+			want:   []float64{hashedPriority("DevNginxDemo"), hashedPriority("DevNginxDemo")},
+			errs: []string{
+				fmt.Sprintf("listener rule priority %v is claimed by DevApi, DevNginxDemo, set an explicit Priority so that they differ", hashedPriority("DevNginxDemo")),
+			},
+		},
+		{
+			name:   "explicit priorities collide",
+			claims: []claim{{"Web", 5}, {"Api", 5}, {"Admin", 6}},
+			want:   []float64{5, 5, 6},
+			errs:   []string{"listener rule priority 5 is claimed by Api, Web, set an explicit Priority so that they differ"},
+		},
+		{
+			name:   "key used twice",
+			claims: []claim{{"Api", 0}, {"Api", 0}},
+			want:   []float64{hashedPriority("Api"), hashedPriority("Api")},
+			errs:   []string{`listener rule key "Api" is used by 2 rules`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priorities := newListenerRulePriorities()
+			var got []float64
+			for _, c := range tt.claims {
+				got = append(got, priorities.claim(c.key, c.priority))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("claim() = %v, want %v", got, tt.want)
+			}
+			if errs := priorities.validate(); !reflect.DeepEqual(errs, tt.errs) {
+				t.Errorf("validate() = %q, want %q", errs, tt.errs)
+			}
+		})
+	}
+}
+
+func TestHashedPriority(t *testing.T) {
+	// Priorities are part of the deployed listener rules, so the hash must not
+	// change between releases.
+	if got := hashedPriority("DevNginxDemo"); got != 14592 {
+		t.Errorf("hashedPriority(DevNginxDemo) = %v, want 14592", got)
+	}
+	for _, key := range []string{"", "a", "PrdNginxDemo", "StgApi"} {
+		if got := hashedPriority(key); got < 1 || got > maxListenerRulePriority {
+			t.Errorf("hashedPriority(%q) = %v, want between 1 and %d", key, got, maxListenerRulePriority)
+		}
+	}
+}
+
+func TestContainerServiceListenerRulePriority(t *testing.T) {
+	// GIVEN
+	props := testServiceProps()
+	props.ListenerRule.Priority = 0
+
+	// WHEN
+	template := synthService(&props)
+
+	// THEN
+	template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), map[string]interface{}{
+		"Priority": hashedPriority("Nginx"),
+	})
+}
+
+func TestListenerRuleKeyUsedTwice(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	for _, id := range []string{"First", "Second"} {
+		props := testServiceProps()
+		props.ListenerRule.Priority = 0
+		NewContainerService(stack, jsii.String(id), compute, &props)
+	}
+
+	// THEN
+	want := `listener rule key "Nginx" is used by 2 rules`
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("Synth() panic = %v, want %s", r, want)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
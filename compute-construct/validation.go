@@ -0,0 +1,1012 @@
+package breezeware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	autoscaling "github.com/aws/aws-cdk-go/awscdk/v2/awsautoscaling"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	ecs "github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	"github.com/aws/jsii-runtime-go"
+)
+
+var (
+	resourceNamePattern     = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	elbNamePattern          = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	vpcIdPattern            = regexp.MustCompile(`^vpc-[0-9a-f]{8,17}$`)
+	prefixListPattern       = regexp.MustCompile(`^pl-[0-9a-f]+$`)
+	amiIdPattern            = regexp.MustCompile(`^ami-[0-9a-f]{8,17}$`)
+	kmsKeyPattern           = regexp.MustCompile(`^arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:(key|alias)/[a-zA-Z0-9/_-]+$`)
+	ssmDocumentNamePattern  = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,128}$`)
+	subnetIdPattern         = regexp.MustCompile(`^subnet-[0-9a-f]{8,17}$`)
+	acmCertificatePattern   = regexp.MustCompile(`^arn:aws[a-z-]*:acm:[a-z0-9-]+:[0-9]{12}:certificate/[a-zA-Z0-9-]+$`)
+	iamPolicyArnPattern     = regexp.MustCompile(`^arn:aws[a-z-]*:iam::(aws|[0-9]{12}):policy/[\w+=,.@/-]+$`)
+	iamPolicyNamePattern    = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+	dockerPluginPattern     = regexp.MustCompile(`^[a-z0-9]+([._/-][a-z0-9]+)*(:[\w.-]+)?$`)
+	pluginSettingPattern    = regexp.MustCompile(`^[A-Z0-9_]+$`)
+	availabilityZonePattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9][a-z]$`)
+	hostedZoneIdPattern     = regexp.MustCompile(`^Z[A-Z0-9]{1,32}$`)
+	domainNamePattern       = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,63}$`)
+	s3BucketNamePattern     = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	reservedProviderPrefix  = []string{"aws", "ecs", "fargate"}
+)
+
+const (
+	maxElbNameLength        = 32
+	maxListenerRulePriority = 50000
+	// An ALB listener takes 25 certificates besides its default one.
+	maxListenerCertificates = 26
+	maxResourceNameLength   = 255
+	maxIdleTimeoutSeconds   = 4000
+)
+
+// ValidationError collects every props violation found in a single pass so
+// they can be reported together instead of one deploy failure at a time.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d validation error(s):\n  - %s", len(e.Errors), strings.Join(e.Errors, "\n  - "))
+}
+
+func newValidationError(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// propsValidation adapts a props validator to constructs.IValidation so the
+// errors surface during `cdk synth`.
+type propsValidation struct {
+	validate func() []string
+}
+
+func (v *propsValidation) Validate() *[]*string {
+	return jsii.Strings(v.validate()...)
+}
+
+// withPrefix qualifies errors with the path of the nested props they came
+// from. Errors start with a field name, an index or a lower case sentence.
+func withPrefix(prefix string, errs []string) []string {
+	prefixed := make([]string, 0, len(errs))
+	for _, err := range errs {
+		switch {
+		case strings.HasPrefix(err, "["):
+			prefixed = append(prefixed, prefix+err)
+		case err[:1] == strings.ToLower(err[:1]):
+			prefixed = append(prefixed, prefix+": "+err)
+		default:
+			prefixed = append(prefixed, prefix+"."+err)
+		}
+	}
+	return prefixed
+}
+
+func validateName(field, value string, maxLength int) []string {
+	var errs []string
+	if value == "" {
+		return append(errs, field+" is required")
+	}
+	if len(value) > maxLength {
+		errs = append(errs, fmt.Sprintf("%s %q is %d characters, the maximum is %d", field, value, len(value), maxLength))
+	}
+	if !resourceNamePattern.MatchString(value) {
+		errs = append(errs, fmt.Sprintf("%s %q may only contain letters, numbers, hyphens and underscores", field, value))
+	}
+	return errs
+}
+
+func validateElbName(field, value string) []string {
+	var errs []string
+	if value == "" {
+		return append(errs, field+" is required")
+	}
+	if len(value) > maxElbNameLength {
+		errs = append(errs, fmt.Sprintf("%s %q is %d characters, the maximum is %d", field, value, len(value), maxElbNameLength))
+	}
+	if !elbNamePattern.MatchString(value) {
+		errs = append(errs, fmt.Sprintf("%s %q may only contain letters, numbers and hyphens and must not start or end with a hyphen", field, value))
+	}
+	return errs
+}
+
+func (p *ContainerComputeProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeProps) validate() []string {
+	var errs []string
+
+	if p.VpcId != nil {
+		if !vpcIdPattern.MatchString(*p.VpcId) {
+			errs = append(errs, fmt.Sprintf("VpcId %q is not a valid VPC ID", *p.VpcId))
+		}
+	} else {
+		errs = append(errs, withPrefix("Vpc", p.Vpc.validate())...)
+	}
+
+	errs = append(errs, withPrefix("Cluster", p.Cluster.validate())...)
+
+	if p.Cluster.IsAsgCapacityProviderEnabled && len(p.AsgCapacityProviders) == 0 {
+		errs = append(errs, "AsgCapacityProviders must not be empty when Cluster.IsAsgCapacityProviderEnabled is true")
+	}
+
+	asgNames := make(map[string]bool)
+	providerNames := make(map[string]bool)
+	for i, asgCapacityProvider := range p.AsgCapacityProviders {
+		prefix := fmt.Sprintf("AsgCapacityProviders[%d]", i)
+		errs = append(errs, withPrefix(prefix, asgCapacityProvider.validate())...)
+
+		if name := asgCapacityProvider.AutoScalingGroup.Name; name != "" {
+			if asgNames[name] {
+				errs = append(errs, fmt.Sprintf("%s.AutoScalingGroup.Name %q is used more than once", prefix, name))
+			}
+			asgNames[name] = true
+		}
+		if asgCapacityProvider.AutoScalingGroup.SubnetType == ec2.SubnetType_PRIVATE_ISOLATED && !p.VpcEndpoints.Enabled {
+			errs = append(errs, fmt.Sprintf("%s.AutoScalingGroup.SubnetType PRIVATE_ISOLATED needs VpcEndpoints.Enabled so instances can reach ECS, ECR and CloudWatch Logs", prefix))
+		}
+		if name := asgCapacityProvider.CapacityProvider.Name; name != "" {
+			if providerNames[name] {
+				errs = append(errs, fmt.Sprintf("%s.CapacityProvider.Name %q is used more than once", prefix, name))
+			}
+			providerNames[name] = true
+		}
+	}
+
+	errs = append(errs, withPrefix("Cluster.DefaultCapacityProviderStrategy", validateCapacityProviderStrategy(p.Cluster.DefaultCapacityProviderStrategy, p.capacityProviderNames()))...)
+
+	errs = append(errs, withPrefix("LoadBalancer", p.LoadBalancer.validate())...)
+	errs = append(errs, withPrefix("CloudmapNamespace", p.CloudmapNamespace.validate())...)
+	if p.SessionLogging.enabled() {
+		sessionLogging := p.SessionLogging
+		if sessionLogging.DocumentName == "" {
+			sessionLogging.DocumentName = p.Cluster.Name + "SessionManagerRunShell"
+		}
+		errs = append(errs, withPrefix("SessionLogging", sessionLogging.validate())...)
+	} else if p.SessionLogging.DocumentName != "" || p.SessionLogging.LogGroupName != "" {
+		errs = append(errs, "SessionLogging needs LogToS3 or LogToCloudWatch")
+	}
+
+	return errs
+}
+
+// capacityProviderNames lists the capacity providers NewContainerCompute will
+// actually create for these props.
+func (p *ContainerComputeProps) capacityProviderNames() map[string]bool {
+	names := make(map[string]bool)
+	if p.Cluster.IsFargateCapacityProviderEnabled {
+		names[FargateCapacityProvider] = true
+		names[FargateSpotCapacityProvider] = true
+	}
+	if p.Cluster.IsAsgCapacityProviderEnabled {
+		for _, asgCapacityProvider := range p.AsgCapacityProviders {
+			names[asgCapacityProvider.CapacityProvider.Name] = true
+		}
+	}
+	return names
+}
+
+// validateCapacityProviderStrategy checks a strategy against the ECS rules and,
+// when available is not nil, against the capacity providers that exist.
+func validateCapacityProviderStrategy(strategies []CapacityProviderStrategyProps, available map[string]bool) []string {
+	var errs []string
+	if len(strategies) == 0 {
+		return errs
+	}
+
+	var withBase, withWeight, fargate, asg int
+	seen := make(map[string]bool)
+	for i, strategy := range strategies {
+		switch {
+		case strategy.CapacityProvider == "":
+			errs = append(errs, fmt.Sprintf("[%d].CapacityProvider is required", i))
+		case available != nil && !available[strategy.CapacityProvider]:
+			errs = append(errs, fmt.Sprintf("[%d].CapacityProvider %q is not one of the capacity providers on the cluster (%s)", i, strategy.CapacityProvider, strings.Join(sortedKeys(available), ", ")))
+		}
+		if seen[strategy.CapacityProvider] {
+			errs = append(errs, fmt.Sprintf("[%d].CapacityProvider %q is used more than once", i, strategy.CapacityProvider))
+		}
+		seen[strategy.CapacityProvider] = true
+
+		if strategy.Base < 0 || strategy.Base > 100000 {
+			errs = append(errs, fmt.Sprintf("[%d].Base (%v) must be between 0 and 100000", i, strategy.Base))
+		}
+		if strategy.Weight < 0 || strategy.Weight > 1000 {
+			errs = append(errs, fmt.Sprintf("[%d].Weight (%v) must be between 0 and 1000", i, strategy.Weight))
+		}
+		if strategy.Base > 0 {
+			withBase++
+		}
+		if strategy.Weight > 0 {
+			withWeight++
+		}
+		if isFargateCapacityProvider(strategy.CapacityProvider) {
+			fargate++
+		} else {
+			asg++
+		}
+	}
+
+	if withBase > 1 {
+		errs = append(errs, "only one capacity provider may have a Base")
+	}
+	if withWeight == 0 {
+		errs = append(errs, "at least one capacity provider must have a Weight greater than zero")
+	}
+	if fargate > 0 && asg > 0 {
+		errs = append(errs, "cannot mix Fargate and Auto Scaling group capacity providers in one strategy")
+	}
+	return errs
+}
+
+func (p *ContainerComputeVpcProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeVpcProps) validate() []string {
+	var errs []string
+	if p.Cidr != "" {
+		if _, _, err := net.ParseCIDR(p.Cidr); err != nil {
+			errs = append(errs, fmt.Sprintf("Cidr %q is not a valid CIDR block", p.Cidr))
+		}
+	}
+	if p.MaxAzs < 0 {
+		errs = append(errs, fmt.Sprintf("MaxAzs (%v) must not be negative", p.MaxAzs))
+	}
+	if p.NatGateways < 0 {
+		errs = append(errs, fmt.Sprintf("NatGateways (%v) must not be negative", p.NatGateways))
+	}
+	if p.MaxAzs > 0 && p.NatGateways > p.MaxAzs {
+		errs = append(errs, fmt.Sprintf("NatGateways (%v) is greater than MaxAzs (%v)", p.NatGateways, p.MaxAzs))
+	}
+	return errs
+}
+
+func (p *ContainerComputeClusterProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeClusterProps) validate() []string {
+	return validateName("Name", p.Name, maxResourceNameLength)
+}
+
+func (p *AutoscalinGroupCapacityProviders) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *AutoscalinGroupCapacityProviders) validate() []string {
+	var errs []string
+	errs = append(errs, withPrefix("AutoScalingGroup", p.AutoScalingGroup.validate())...)
+	errs = append(errs, withPrefix("CapacityProvider", p.CapacityProvider.validate())...)
+	if p.CapacityProvider.BlockContainerInstanceRoleAccess && p.AutoScalingGroup.EcsAgent.AllowAwsvpcImds {
+		errs = append(errs, "CapacityProvider.BlockContainerInstanceRoleAccess conflicts with AutoScalingGroup.EcsAgent.AllowAwsvpcImds")
+	}
+	return errs
+}
+
+func (p *ContainerComputeAsgProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeAsgProps) validate() []string {
+	var errs []string
+	errs = append(errs, validateName("Name", p.Name, maxResourceNameLength)...)
+
+	if p.MinCapacity < 0 {
+		errs = append(errs, fmt.Sprintf("MinCapacity (%v) must not be negative", p.MinCapacity))
+	}
+	if p.MaxCapacity <= 0 {
+		errs = append(errs, fmt.Sprintf("MaxCapacity (%v) must be greater than zero", p.MaxCapacity))
+	}
+	if p.MinCapacity > p.MaxCapacity {
+		errs = append(errs, fmt.Sprintf("MinCapacity (%v) is greater than MaxCapacity (%v)", p.MinCapacity, p.MaxCapacity))
+	}
+	if p.DesiredCapacity != 0 && (p.DesiredCapacity < p.MinCapacity || p.DesiredCapacity > p.MaxCapacity) {
+		errs = append(errs, fmt.Sprintf("DesiredCapacity (%v) must be between MinCapacity (%v) and MaxCapacity (%v)", p.DesiredCapacity, p.MinCapacity, p.MaxCapacity))
+	}
+	if p.InstanceClass == "" {
+		errs = append(errs, "InstanceClass is required")
+	}
+	if p.InstanceSize == "" {
+		errs = append(errs, "InstanceSize is required")
+	}
+	errs = append(errs, withPrefix("MachineImage", p.MachineImage.validate())...)
+	errs = append(errs, withPrefix("EcsAgent", p.EcsAgent.validate())...)
+	errs = append(errs, withPrefix("MixedInstances", p.validateMixedInstances())...)
+	errs = append(errs, withPrefix("Access", p.Access.validate())...)
+	if p.Access.Mode == InstanceAccessNone && p.MachineImage.Family == MachineImageBottlerocket {
+		errs = append(errs, fmt.Sprintf("Access.Mode %s is not possible on %s hosts, which always get Session Manager access", InstanceAccessNone, MachineImageBottlerocket))
+	}
+	errs = append(errs, withPrefix("Role", p.Role.validate())...)
+	if p.MetadataHopLimit < 0 || p.MetadataHopLimit > 64 {
+		errs = append(errs, fmt.Sprintf("MetadataHopLimit (%v) must be between 1 and 64", p.MetadataHopLimit))
+	}
+	errs = append(errs, withPrefix("RootVolume", p.RootVolume.validate())...)
+	errs = append(errs, p.validateVolumePlugins()...)
+	for i, hook := range p.UserDataHooks {
+		if hook == nil {
+			errs = append(errs, fmt.Sprintf("UserDataHooks[%d] is nil", i))
+		}
+	}
+	return errs
+}
+
+// validateMixedInstances checks the mixed instances policy against the group's
+// own instance type, which every override has to match in architecture because
+// they share one AMI.
+func (p *ContainerComputeAsgProps) validateMixedInstances() []string {
+	mixed := &p.MixedInstances
+	if !mixed.Enabled {
+		if len(mixed.InstanceTypes) > 0 || mixed.OnDemandBaseCapacity != 0 || mixed.SpotPercentage != 0 || mixed.SpotAllocationStrategy != "" {
+			return []string{"Enabled must be true when mixed instances settings are given"}
+		}
+		return nil
+	}
+
+	var errs []string
+	if mixed.OnDemandBaseCapacity < 0 {
+		errs = append(errs, fmt.Sprintf("OnDemandBaseCapacity (%v) must not be negative", mixed.OnDemandBaseCapacity))
+	}
+	if mixed.SpotPercentage < 0 || mixed.SpotPercentage > 100 {
+		errs = append(errs, fmt.Sprintf("SpotPercentage (%v) must be between 0 and 100", mixed.SpotPercentage))
+	}
+	switch mixed.SpotAllocationStrategy {
+	case "", autoscaling.SpotAllocationStrategy_LOWEST_PRICE, autoscaling.SpotAllocationStrategy_CAPACITY_OPTIMIZED,
+		autoscaling.SpotAllocationStrategy_CAPACITY_OPTIMIZED_PRIORITIZED, autoscaling.SpotAllocationStrategy_PRICE_CAPACITY_OPTIMIZED:
+	default:
+		errs = append(errs, fmt.Sprintf("SpotAllocationStrategy %q is not supported", mixed.SpotAllocationStrategy))
+	}
+
+	if p.InstanceClass == "" || p.InstanceSize == "" {
+		return errs
+	}
+	architecture := instanceArchitecture(p)
+	seen := map[string]bool{fmt.Sprintf("%s.%s", p.InstanceClass, p.InstanceSize): true}
+	for i, instanceType := range mixed.InstanceTypes {
+		if instanceType.InstanceClass == "" || instanceType.InstanceSize == "" {
+			errs = append(errs, fmt.Sprintf("InstanceTypes[%d] needs both InstanceClass and InstanceSize", i))
+			continue
+		}
+		name := fmt.Sprintf("%s.%s", instanceType.InstanceClass, instanceType.InstanceSize)
+		if seen[name] {
+			errs = append(errs, fmt.Sprintf("InstanceTypes[%d] %s is listed more than once", i, name))
+		}
+		seen[name] = true
+		if other := ec2.InstanceType_Of(instanceType.InstanceClass, instanceType.InstanceSize).Architecture(); other != architecture {
+			errs = append(errs, fmt.Sprintf("InstanceTypes[%d] %s is %s but the group's instance type is %s", i, name, other, architecture))
+		}
+	}
+	return errs
+}
+
+// validateVolumePlugins checks that the plugins can run on the group's hosts.
+// Bottlerocket does not support Docker plugins and rexray/ebs is x86_64 only.
+func (p *ContainerComputeAsgProps) validateVolumePlugins() []string {
+	var errs []string
+	drivers := map[string]bool{LocalVolumeDriver: true}
+	for i := range p.VolumePlugins {
+		plugin := &p.VolumePlugins[i]
+		prefix := fmt.Sprintf("VolumePlugins[%d]", i)
+		if !dockerPluginPattern.MatchString(plugin.Name) {
+			errs = append(errs, fmt.Sprintf("%s.Name %q is not a valid plugin reference", prefix, plugin.Name))
+		}
+		if plugin.Alias != "" && !dockerPluginPattern.MatchString(plugin.Alias) {
+			errs = append(errs, fmt.Sprintf("%s.Alias %q is not a valid plugin reference", prefix, plugin.Alias))
+		}
+		if drivers[plugin.driver()] {
+			errs = append(errs, fmt.Sprintf("%s driver %q is already installed", prefix, plugin.driver()))
+		}
+		drivers[plugin.driver()] = true
+		for _, key := range sortedKeys(plugin.Settings) {
+			if !pluginSettingPattern.MatchString(key) {
+				errs = append(errs, fmt.Sprintf("%s.Settings key %q may only contain upper-case letters, digits and underscores", prefix, key))
+			}
+		}
+		if p.MachineImage.Family == MachineImageBottlerocket {
+			errs = append(errs, fmt.Sprintf("%s %s cannot be installed on %s hosts", prefix, plugin.Name, MachineImageBottlerocket))
+		} else if plugin.Name == RexrayEbsVolumePlugin && p.InstanceClass != "" && p.InstanceSize != "" && instanceArchitecture(p) != ec2.InstanceArchitecture_X86_64 {
+			errs = append(errs, fmt.Sprintf("%s %s is only published for x86_64", prefix, plugin.Name))
+		}
+	}
+	return errs
+}
+
+func (p *InstanceAccessProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *InstanceAccessProps) validate() []string {
+	var errs []string
+	switch p.Mode {
+	case "", InstanceAccessSessionManager, InstanceAccessNone:
+		if p.SshKeyName != "" || len(p.SshAllowedCidrs) > 0 {
+			errs = append(errs, fmt.Sprintf("SshKeyName and SshAllowedCidrs need Mode %s", InstanceAccessSsh))
+		}
+	case InstanceAccessSsh:
+		if p.SshKeyName == "" {
+			errs = append(errs, fmt.Sprintf("SshKeyName is required when Mode is %s", InstanceAccessSsh))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("Mode %q is not one of %s, %s, %s", p.Mode, InstanceAccessSessionManager, InstanceAccessSsh, InstanceAccessNone))
+	}
+	for i, cidr := range p.SshAllowedCidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err != nil || ip.To4() == nil {
+			errs = append(errs, fmt.Sprintf("SshAllowedCidrs[%d] %q is not a valid IPv4 CIDR block", i, cidr))
+		}
+	}
+	return errs
+}
+
+func (p *ContainerComputeAsgRoleProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeAsgRoleProps) validate() []string {
+	var errs []string
+	for i, arn := range p.ManagedPolicyArns {
+		if !iamPolicyArnPattern.MatchString(arn) {
+			errs = append(errs, fmt.Sprintf("ManagedPolicyArns[%d] %q is not a valid IAM policy ARN", i, arn))
+		}
+	}
+	for _, name := range sortedKeys(p.InlinePolicies) {
+		if name == ebsVolumePolicyName {
+			errs = append(errs, fmt.Sprintf("InlinePolicies name %q is reserved", name))
+		} else if !iamPolicyNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("InlinePolicies name %q must be 1 to 128 letters, digits or +=,.@_-", name))
+		}
+		if p.InlinePolicies[name] == nil {
+			errs = append(errs, fmt.Sprintf("InlinePolicies[%s] is nil", name))
+			continue
+		}
+		for _, err := range *p.InlinePolicies[name].ValidateForIdentityPolicy() {
+			errs = append(errs, fmt.Sprintf("InlinePolicies[%s]: %s", name, *err))
+		}
+	}
+	if p.PermissionsBoundaryArn != "" && !iamPolicyArnPattern.MatchString(p.PermissionsBoundaryArn) {
+		errs = append(errs, fmt.Sprintf("PermissionsBoundaryArn %q is not a valid IAM policy ARN", p.PermissionsBoundaryArn))
+	}
+	return errs
+}
+
+func (p *SessionLoggingProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *SessionLoggingProps) validate() []string {
+	var errs []string
+	if !p.enabled() {
+		errs = append(errs, "at least one of LogToS3 and LogToCloudWatch must be set")
+	}
+	if !ssmDocumentNamePattern.MatchString(p.DocumentName) {
+		errs = append(errs, fmt.Sprintf("DocumentName %q must be 3 to 128 letters, digits, '_', '-' or '.'", p.DocumentName))
+	}
+	lower := strings.ToLower(p.DocumentName)
+	if strings.HasPrefix(lower, "aws") || strings.HasPrefix(lower, "amazon") {
+		errs = append(errs, fmt.Sprintf("DocumentName %q must not start with aws or amazon", p.DocumentName))
+	}
+	if p.LogGroupName != "" && !p.LogToCloudWatch {
+		errs = append(errs, "LogGroupName needs LogToCloudWatch")
+	}
+	return errs
+}
+
+func (p *ContainerComputeRootVolumeProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeRootVolumeProps) validate() []string {
+	var errs []string
+	if p.SizeGiB < 0 || p.SizeGiB > 16384 {
+		errs = append(errs, fmt.Sprintf("SizeGiB (%v) must be between 1 and 16384", p.SizeGiB))
+	}
+	switch p.VolumeType {
+	case "", ec2.EbsDeviceVolumeType_GP2, ec2.EbsDeviceVolumeType_STANDARD, ec2.EbsDeviceVolumeType_ST1, ec2.EbsDeviceVolumeType_SC1:
+		if p.Iops != 0 && p.VolumeType != "" {
+			errs = append(errs, fmt.Sprintf("Iops cannot be set for %s volumes", p.VolumeType))
+		}
+	case ec2.EbsDeviceVolumeType_GP3, ec2.EbsDeviceVolumeType_IO1, ec2.EbsDeviceVolumeType_IO2:
+		if p.VolumeType != ec2.EbsDeviceVolumeType_GP3 && p.Iops == 0 {
+			errs = append(errs, fmt.Sprintf("Iops is required for %s volumes", p.VolumeType))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("VolumeType %q is not supported", p.VolumeType))
+	}
+	if p.Iops < 0 {
+		errs = append(errs, fmt.Sprintf("Iops (%v) must not be negative", p.Iops))
+	}
+	if p.KmsKeyArn != "" && !kmsKeyPattern.MatchString(p.KmsKeyArn) {
+		errs = append(errs, fmt.Sprintf("KmsKeyArn %q is not a valid KMS key ARN", p.KmsKeyArn))
+	}
+	return errs
+}
+
+func (p *ContainerComputeMachineImageProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeMachineImageProps) validate() []string {
+	var errs []string
+	switch p.Family {
+	case "", MachineImageEcsAmazonLinux2, MachineImageEcsAmazonLinux2023, MachineImageBottlerocket:
+		if p.AmiId != "" || p.SsmParameter != "" {
+			errs = append(errs, fmt.Sprintf("AmiId and SsmParameter can only be set when Family is %s", MachineImageCustom))
+		}
+	case MachineImageCustom:
+		if (p.AmiId == "") == (p.SsmParameter == "") {
+			errs = append(errs, "exactly one of AmiId and SsmParameter must be set for a custom image")
+		}
+		if p.AmiId != "" && !amiIdPattern.MatchString(p.AmiId) {
+			errs = append(errs, fmt.Sprintf("AmiId %q is not a valid AMI ID", p.AmiId))
+		}
+		if p.SsmParameter != "" && !strings.HasPrefix(p.SsmParameter, "/") {
+			errs = append(errs, fmt.Sprintf("SsmParameter %q must be a parameter path starting with /", p.SsmParameter))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("Family %q is not one of %s, %s, %s, %s", p.Family, MachineImageEcsAmazonLinux2, MachineImageEcsAmazonLinux2023, MachineImageBottlerocket, MachineImageCustom))
+	}
+	return errs
+}
+
+func (c *EcsAgentConfig) Validate() error {
+	return newValidationError(c.validate())
+}
+
+func (c *EcsAgentConfig) validate() []string {
+	var errs []string
+	switch c.ImagePullBehavior {
+	case "", EcsImagePullDefault, EcsImagePullAlways, EcsImagePullOnce, EcsImagePullPreferCached:
+	default:
+		errs = append(errs, fmt.Sprintf("ImagePullBehavior %q is not one of %s, %s, %s, %s", c.ImagePullBehavior, EcsImagePullDefault, EcsImagePullAlways, EcsImagePullOnce, EcsImagePullPreferCached))
+	}
+	if c.ContainerStopTimeoutSeconds < 0 {
+		errs = append(errs, fmt.Sprintf("ContainerStopTimeoutSeconds (%v) must not be negative", c.ContainerStopTimeoutSeconds))
+	}
+	return errs
+}
+
+func (p *ContainerComputeAsgCapacityProviderProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeAsgCapacityProviderProps) validate() []string {
+	errs := validateName("Name", p.Name, maxResourceNameLength)
+	for _, prefix := range reservedProviderPrefix {
+		if strings.HasPrefix(strings.ToLower(p.Name), prefix) {
+			errs = append(errs, fmt.Sprintf("Name %q must not start with %q", p.Name, prefix))
+		}
+	}
+	if p.TargetCapacityPercent < 0 || p.TargetCapacityPercent > 100 {
+		errs = append(errs, fmt.Sprintf("TargetCapacityPercent (%v) must be between 1 and 100", p.TargetCapacityPercent))
+	}
+	if p.MinimumScalingStepSize < 0 || p.MinimumScalingStepSize > 10000 {
+		errs = append(errs, fmt.Sprintf("MinimumScalingStepSize (%v) must be between 1 and 10000", p.MinimumScalingStepSize))
+	}
+	if p.MaximumScalingStepSize < 0 || p.MaximumScalingStepSize > 10000 {
+		errs = append(errs, fmt.Sprintf("MaximumScalingStepSize (%v) must be between 1 and 10000", p.MaximumScalingStepSize))
+	}
+	if p.MinimumScalingStepSize != 0 && p.MaximumScalingStepSize != 0 && p.MinimumScalingStepSize > p.MaximumScalingStepSize {
+		errs = append(errs, fmt.Sprintf("MinimumScalingStepSize (%v) is greater than MaximumScalingStepSize (%v)", p.MinimumScalingStepSize, p.MaximumScalingStepSize))
+	}
+	if p.InstanceWarmupSeconds < 0 || p.InstanceWarmupSeconds > 10000 {
+		errs = append(errs, fmt.Sprintf("InstanceWarmupSeconds (%v) must be between 0 and 10000", p.InstanceWarmupSeconds))
+	}
+	return errs
+}
+
+func (p *ContainerComputeLoadBalancerProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeLoadBalancerProps) validate() []string {
+	var errs []string
+	errs = append(errs, validateElbName("Name", p.Name)...)
+
+	if !p.Internal && p.SubnetType != "" && p.SubnetType != ec2.SubnetType_PUBLIC {
+		errs = append(errs, fmt.Sprintf("SubnetType %s cannot be used for an internet-facing load balancer, set Internal or use PUBLIC subnets", p.SubnetType))
+	}
+	if len(p.SubnetIds) > 0 && p.SubnetType != "" {
+		errs = append(errs, "SubnetIds and SubnetType are mutually exclusive")
+	}
+	for i, subnetId := range p.SubnetIds {
+		if !subnetIdPattern.MatchString(subnetId) {
+			errs = append(errs, fmt.Sprintf("SubnetIds[%d] %q is not a valid subnet ID", i, subnetId))
+		}
+	}
+	for i, cidr := range p.AllowedCidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err != nil || ip.To4() == nil {
+			errs = append(errs, fmt.Sprintf("AllowedCidrs[%d] %q is not a valid IPv4 CIDR block", i, cidr))
+		}
+	}
+	for i, prefixList := range p.AllowedPrefixLists {
+		if !prefixListPattern.MatchString(prefixList) {
+			errs = append(errs, fmt.Sprintf("AllowedPrefixLists[%d] %q is not a valid prefix list ID", i, prefixList))
+		}
+	}
+
+	errs = append(errs, p.validateCertificates()...)
+	errs = append(errs, withPrefix("DefaultAction", p.DefaultAction.validate())...)
+	errs = append(errs, withPrefix("Hardening", p.Hardening.validate())...)
+	errs = append(errs, withPrefix("AccessLogs", p.AccessLogs.validate())...)
+	return errs
+}
+
+func (p *ContainerComputeAccessLogsProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeAccessLogsProps) validate() []string {
+	var errs []string
+	if !p.Enabled {
+		if p.BucketName != "" || p.Prefix != "" || p.lifecycleEnabled() {
+			errs = append(errs, "BucketName, Prefix and the lifecycle days need Enabled")
+		}
+		return errs
+	}
+
+	if p.BucketName != "" {
+		if !s3BucketNamePattern.MatchString(p.BucketName) {
+			errs = append(errs, fmt.Sprintf("BucketName %q must be 3 to 63 lowercase letters, digits, '.' or '-'", p.BucketName))
+		}
+		if p.lifecycleEnabled() {
+			errs = append(errs, fmt.Sprintf("lifecycle days only apply to a created bucket, not to BucketName %q", p.BucketName))
+		}
+	}
+	if strings.HasPrefix(p.Prefix, "/") || strings.HasSuffix(p.Prefix, "/") || strings.Contains(p.Prefix, "//") {
+		errs = append(errs, fmt.Sprintf("Prefix %q must not start or end with '/' or contain '//'", p.Prefix))
+	}
+	if strings.Contains(p.Prefix, "AWSLogs") {
+		errs = append(errs, fmt.Sprintf("Prefix %q must not contain AWSLogs", p.Prefix))
+	}
+
+	if p.InfrequentAccessAfterDays < 0 || p.GlacierAfterDays < 0 || p.ExpirationDays < 0 {
+		errs = append(errs, "lifecycle days must not be negative")
+	}
+	if p.InfrequentAccessAfterDays > 0 && p.InfrequentAccessAfterDays < 30 {
+		errs = append(errs, fmt.Sprintf("InfrequentAccessAfterDays (%v) must be at least 30", p.InfrequentAccessAfterDays))
+	}
+	// Objects have to stay in STANDARD_IA for 30 days before moving on.
+	if p.GlacierAfterDays > 0 && p.InfrequentAccessAfterDays > 0 && p.GlacierAfterDays < p.InfrequentAccessAfterDays+30 {
+		errs = append(errs, fmt.Sprintf("GlacierAfterDays (%v) must be at least 30 days after InfrequentAccessAfterDays (%v)", p.GlacierAfterDays, p.InfrequentAccessAfterDays))
+	}
+	if last := math.Max(p.InfrequentAccessAfterDays, p.GlacierAfterDays); p.ExpirationDays > 0 && p.ExpirationDays <= last {
+		errs = append(errs, fmt.Sprintf("ExpirationDays (%v) must be later than the last transition (%v)", p.ExpirationDays, last))
+	}
+	return errs
+}
+
+func (p *ContainerComputeLoadBalancerHardeningProps) validate() []string {
+	var errs []string
+	if p.IdleTimeoutSeconds < 0 || p.IdleTimeoutSeconds > maxIdleTimeoutSeconds {
+		errs = append(errs, fmt.Sprintf("IdleTimeoutSeconds (%v) must be between 1 and %d", p.IdleTimeoutSeconds, maxIdleTimeoutSeconds))
+	}
+	return errs
+}
+
+func (p *ContainerComputeLoadBalancerProps) validateCertificates() []string {
+	var errs []string
+	if p.ListenerCertificateArn != "" && !acmCertificatePattern.MatchString(p.ListenerCertificateArn) {
+		errs = append(errs, fmt.Sprintf("ListenerCertificateArn %q is not a valid ACM certificate ARN", p.ListenerCertificateArn))
+	}
+	for i, arn := range p.CertificateArns {
+		if !acmCertificatePattern.MatchString(arn) {
+			errs = append(errs, fmt.Sprintf("CertificateArns[%d] %q is not a valid ACM certificate ARN", i, arn))
+		}
+	}
+
+	if p.HostedZone.Id != "" && !hostedZoneIdPattern.MatchString(p.HostedZone.Id) {
+		errs = append(errs, fmt.Sprintf("HostedZone.Id %q is not a valid hosted zone ID", p.HostedZone.Id))
+	}
+	if p.HostedZone.Id != "" && p.HostedZone.Name == "" {
+		errs = append(errs, "HostedZone.Name is required when HostedZone.Id is set")
+	}
+	zoneName := strings.TrimSuffix(p.HostedZone.Name, ".")
+	if len(p.CertificateDomainNames) > 0 && zoneName == "" {
+		errs = append(errs, "CertificateDomainNames need HostedZone.Name for DNS validation")
+	}
+	seen := make(map[string]bool)
+	for i, domainName := range p.CertificateDomainNames {
+		if !domainNamePattern.MatchString(domainName) {
+			errs = append(errs, fmt.Sprintf("CertificateDomainNames[%d] %q is not a valid lower-case domain name", i, domainName))
+		} else if zoneName != "" && domainName != zoneName && !strings.HasSuffix(domainName, "."+zoneName) {
+			errs = append(errs, fmt.Sprintf("CertificateDomainNames[%d] %q is not in hosted zone %s", i, domainName, zoneName))
+		}
+		if seen[domainName] {
+			errs = append(errs, fmt.Sprintf("CertificateDomainNames[%d] %q is listed more than once", i, domainName))
+		}
+		seen[domainName] = true
+	}
+
+	count := len(p.CertificateArns) + len(p.CertificateDomainNames)
+	if p.ListenerCertificateArn != "" {
+		count++
+	}
+	if count == 0 {
+		errs = append(errs, "one of ListenerCertificateArn, CertificateArns and CertificateDomainNames is required")
+	}
+	if count > maxListenerCertificates {
+		errs = append(errs, fmt.Sprintf("the listener has %d certificates, the maximum is %d", count, maxListenerCertificates))
+	}
+	return errs
+}
+
+func (p *ContainerComputeDefaultActionProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeDefaultActionProps) validate() []string {
+	var errs []string
+	fixedResponse := p.StatusCode != 0 || p.ContentType != "" || p.MessageBody != ""
+	redirect := p.RedirectHost != "" || p.RedirectPath != "" || p.RedirectPort != "" || p.RedirectProtocol != "" || p.RedirectQuery != "" || p.Permanent
+	forward := p.ServiceName != "" || p.HealthCheckPath != "" || p.HealthyHttpCodes != ""
+
+	switch p.Type {
+	case "", DefaultActionFixedResponse:
+		if redirect || forward {
+			errs = append(errs, fmt.Sprintf("only StatusCode, ContentType and MessageBody apply to %s", DefaultActionFixedResponse))
+		}
+		if p.StatusCode != 0 && (p.StatusCode < 200 || p.StatusCode > 599 || (p.StatusCode >= 300 && p.StatusCode < 400)) {
+			errs = append(errs, fmt.Sprintf("StatusCode (%v) must be a 2XX, 4XX or 5XX code", p.StatusCode))
+		}
+		switch p.ContentType {
+		case "", "text/plain", "text/css", "text/html", "application/javascript", "application/json":
+		default:
+			errs = append(errs, fmt.Sprintf("ContentType %q is not supported by fixed responses", p.ContentType))
+		}
+		if len(p.MessageBody) > 1024 {
+			errs = append(errs, fmt.Sprintf("MessageBody is %d bytes, the maximum is 1024", len(p.MessageBody)))
+		}
+	case DefaultActionRedirect:
+		if fixedResponse || forward {
+			errs = append(errs, fmt.Sprintf("only Redirect fields and Permanent apply to %s", DefaultActionRedirect))
+		}
+		// A redirect to the same host, path, port and protocol would loop.
+		if p.RedirectHost == "" && p.RedirectPath == "" && p.RedirectPort == "" && p.RedirectProtocol == "" {
+			errs = append(errs, "at least one of RedirectHost, RedirectPath, RedirectPort and RedirectProtocol is required")
+		}
+		switch p.RedirectProtocol {
+		case "", "HTTP", "HTTPS", "#{protocol}":
+		default:
+			errs = append(errs, fmt.Sprintf("RedirectProtocol %q is not one of HTTP, HTTPS", p.RedirectProtocol))
+		}
+		if p.RedirectPath != "" && !strings.HasPrefix(p.RedirectPath, "/") {
+			errs = append(errs, fmt.Sprintf("RedirectPath %q must start with /", p.RedirectPath))
+		}
+	case DefaultActionForward:
+		if fixedResponse || redirect {
+			errs = append(errs, fmt.Sprintf("only ServiceName, HealthCheckPath and HealthyHttpCodes apply to %s", DefaultActionForward))
+		}
+		errs = append(errs, validateElbName("ServiceName", p.ServiceName)...)
+		if p.HealthCheckPath != "" && !strings.HasPrefix(p.HealthCheckPath, "/") {
+			errs = append(errs, fmt.Sprintf("HealthCheckPath %q must start with /", p.HealthCheckPath))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("Type %q is not one of %s, %s, %s", p.Type, DefaultActionFixedResponse, DefaultActionRedirect, DefaultActionForward))
+	}
+	return errs
+}
+
+func (p *ContainerComputeCloudmapNamespaceProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerComputeCloudmapNamespaceProps) validate() []string {
+	var errs []string
+	if p.Name == "" {
+		errs = append(errs, "Name is required")
+	}
+	return errs
+}
+
+func (p *ContainerServiceProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerServiceProps) validate() []string {
+	var errs []string
+	errs = append(errs, validateName("Name", p.Name, maxResourceNameLength)...)
+
+	if p.DesiredCount < 0 {
+		errs = append(errs, fmt.Sprintf("DesiredCount (%v) must not be negative", p.DesiredCount))
+	}
+	if p.TaskDefinition.Family == "" {
+		errs = append(errs, "TaskDefinition.Family is required")
+	}
+
+	errs = append(errs, withPrefix("Container", p.Container.validate())...)
+
+	errs = append(errs, withPrefix("CapacityProviderStrategies", validateCapacityProviderStrategy(p.CapacityProviderStrategies, nil))...)
+
+	errs = append(errs, withPrefix("TargetGroup", validateElbName("Name", p.TargetGroup.Name))...)
+
+	if p.ListenerRule.Priority < 0 || p.ListenerRule.Priority > maxListenerRulePriority || p.ListenerRule.Priority != float64(int(p.ListenerRule.Priority)) {
+		errs = append(errs, fmt.Sprintf("ListenerRule.Priority (%v) must be a whole number between 1 and %d, or 0 to have one assigned", p.ListenerRule.Priority, maxListenerRulePriority))
+	}
+	// A rule without conditions matches every request and, wherever its hashed
+	// priority lands, shadows the rules after it.
+	if len(p.ListenerRule.HostHeaders) == 0 && len(p.ListenerRule.PathPatterns) == 0 {
+		errs = append(errs, "ListenerRule needs HostHeaders or PathPatterns")
+	}
+
+	if p.IdleTimeoutSeconds < 0 || p.IdleTimeoutSeconds > maxIdleTimeoutSeconds {
+		errs = append(errs, fmt.Sprintf("IdleTimeoutSeconds (%v) must be between 1 and %d", p.IdleTimeoutSeconds, maxIdleTimeoutSeconds))
+	}
+
+	volumeNames := make(map[string]bool)
+	for i := range p.Volumes {
+		prefix := fmt.Sprintf("Volumes[%d]", i)
+		errs = append(errs, withPrefix(prefix, p.Volumes[i].validate())...)
+		if volumeNames[p.Volumes[i].Name] {
+			errs = append(errs, fmt.Sprintf("%s.Name %q is used more than once", prefix, p.Volumes[i].Name))
+		}
+		volumeNames[p.Volumes[i].Name] = true
+	}
+
+	if p.AvailabilityZone != "" && !availabilityZonePattern.MatchString(p.AvailabilityZone) {
+		errs = append(errs, fmt.Sprintf("AvailabilityZone %q is not a valid availability zone name", p.AvailabilityZone))
+	}
+	// Services with EBS volumes are single-AZ: every task has to run where
+	// the volume is, and only one task can hold it at a time.
+	if p.hasZonalVolumes() {
+		if p.AvailabilityZone == "" {
+			errs = append(errs, "AvailabilityZone is required for services with EBS volumes")
+		}
+		if p.DesiredCount > 1 {
+			errs = append(errs, fmt.Sprintf("DesiredCount (%v) must be at most 1 for services with EBS volumes", p.DesiredCount))
+		}
+	}
+
+	return errs
+}
+
+// validateCompute checks the service against the compute it runs on.
+func (p *ContainerServiceProps) validateCompute(compute ContainerCompute) []string {
+	var errs []string
+	if _, ok := compute.DefaultTargetGroups()[p.Name]; ok {
+		if p.TargetGroup.Name != p.Name {
+			errs = append(errs, fmt.Sprintf("TargetGroup.Name %q would be ignored, the service registers into the listener's default target group %q", p.TargetGroup.Name, p.Name))
+		}
+		// The compute owns the default target group's health check.
+		if p.TargetGroup.HealthCheckPath != "" || p.TargetGroup.HealthyHttpCodes != "" {
+			errs = append(errs, "TargetGroup.HealthCheckPath and HealthyHttpCodes would be ignored, set them on the compute's LoadBalancer.DefaultAction")
+		}
+	}
+	// The idle timeout belongs to the shared load balancer, so a service
+	// cannot raise it for everyone else.
+	if p.IdleTimeoutSeconds > compute.IdleTimeoutSeconds() {
+		errs = append(errs, fmt.Sprintf("IdleTimeoutSeconds (%v) is more than the compute's load balancer allows (%v), raise LoadBalancer.Hardening.IdleTimeoutSeconds", p.IdleTimeoutSeconds, compute.IdleTimeoutSeconds()))
+	}
+	errs = append(errs, p.validateVolumeDrivers(compute)...)
+	errs = append(errs, p.validateAvailabilityZone(compute)...)
+	return errs
+}
+
+// validateAvailabilityZone checks that the VPC has subnets in the zone and
+// that the service only runs on EC2 capacity, where placement constraints
+// apply.
+func (p *ContainerServiceProps) validateAvailabilityZone(compute ContainerCompute) []string {
+	if p.AvailabilityZone == "" {
+		return nil
+	}
+	var errs []string
+	var zones []string
+	resolved := true
+	for _, zone := range *compute.Vpc().AvailabilityZones() {
+		resolved = resolved && !*awscdk.Token_IsUnresolved(zone)
+		zones = append(zones, *zone)
+	}
+	if resolved && !containsString(zones, p.AvailabilityZone) {
+		errs = append(errs, fmt.Sprintf("AvailabilityZone %q is not one of the VPC's zones %s", p.AvailabilityZone, strings.Join(zones, ", ")))
+	}
+	field, strategies := p.capacityProviderStrategies(compute)
+	for i, strategy := range strategies {
+		if isFargateCapacityProvider(strategy.CapacityProvider) {
+			errs = append(errs, fmt.Sprintf("%s[%d] %s does not support AvailabilityZone", field, i, strategy.CapacityProvider))
+		}
+	}
+	return errs
+}
+
+// validateForwardTarget checks that the service the forward action names was
+// attached to the compute.
+func (p *ContainerComputeDefaultActionProps) validateForwardTarget(services map[string]bool) []string {
+	if p.Type != DefaultActionForward || p.ServiceName == "" || services[p.ServiceName] {
+		return nil
+	}
+	return []string{fmt.Sprintf("ServiceName %q is not a service on this compute", p.ServiceName)}
+}
+
+// capacityProviderStrategies returns the strategies the service's tasks are
+// placed with and the field they come from. Services without their own get
+// the cluster's default strategy.
+func (p *ContainerServiceProps) capacityProviderStrategies(compute ContainerCompute) (string, []CapacityProviderStrategyProps) {
+	if len(p.CapacityProviderStrategies) > 0 {
+		return "CapacityProviderStrategies", p.CapacityProviderStrategies
+	}
+	return "cluster DefaultCapacityProviderStrategy", compute.DefaultCapacityProviderStrategy()
+}
+
+// validateVolumeDrivers checks that every capacity provider the service runs
+// on has the volume drivers its task definition needs.
+func (p *ContainerServiceProps) validateVolumeDrivers(compute ContainerCompute) []string {
+	if len(p.Volumes) == 0 {
+		return nil
+	}
+	var errs []string
+	field, strategies := p.capacityProviderStrategies(compute)
+	for i, strategy := range strategies {
+		prefix := fmt.Sprintf("%s[%d]", field, i)
+		drivers, ok := compute.CapacityProviderVolumeDrivers()[strategy.CapacityProvider]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s %s cannot run tasks with Docker volumes", prefix, strategy.CapacityProvider))
+			continue
+		}
+		for _, volume := range p.Volumes {
+			if !containsString(drivers, volume.driver()) {
+				errs = append(errs, fmt.Sprintf("%s %s has no %q volume driver for volume %q", prefix, strategy.CapacityProvider, volume.driver(), volume.Name))
+			}
+		}
+	}
+	return errs
+}
+
+func (p *DockerVolumeProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *DockerVolumeProps) validate() []string {
+	var errs []string
+	errs = append(errs, validateName("Name", p.Name, 255)...)
+	if !strings.HasPrefix(p.ContainerPath, "/") {
+		errs = append(errs, fmt.Sprintf("ContainerPath %q must be an absolute path", p.ContainerPath))
+	}
+	if p.Driver != "" && !dockerPluginPattern.MatchString(p.Driver) {
+		errs = append(errs, fmt.Sprintf("Driver %q is not a valid volume driver", p.Driver))
+	}
+	switch p.Scope {
+	case "", ecs.Scope_TASK:
+		if p.Autoprovision {
+			errs = append(errs, fmt.Sprintf("Autoprovision needs Scope %s", ecs.Scope_SHARED))
+		}
+	case ecs.Scope_SHARED:
+	default:
+		errs = append(errs, fmt.Sprintf("Scope %q is not one of %s, %s", p.Scope, ecs.Scope_TASK, ecs.Scope_SHARED))
+	}
+	return errs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ContainerServiceContainerProps) Validate() error {
+	return newValidationError(p.validate())
+}
+
+func (p *ContainerServiceContainerProps) validate() []string {
+	var errs []string
+	if p.Name == "" {
+		errs = append(errs, "Name is required")
+	}
+	if p.Image == "" {
+		errs = append(errs, "Image is required")
+	}
+	if p.ContainerPort <= 0 || p.ContainerPort > 65535 {
+		errs = append(errs, fmt.Sprintf("ContainerPort (%v) must be between 1 and 65535", p.ContainerPort))
+	}
+	if p.MemoryLimitMiB <= 0 {
+		errs = append(errs, fmt.Sprintf("MemoryLimitMiB (%v) must be greater than zero", p.MemoryLimitMiB))
+	}
+	return errs
+}
@@ -0,0 +1,263 @@
+package breezeware
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	iam "github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type validator interface {
+	Validate() error
+}
+
+func TestValidate(t *testing.T) {
+	compute := testComputeProps()
+	service := testServiceProps()
+
+	badVpcId := testComputeProps()
+	badVpcId.VpcId = jsii.String("vpc-1")
+
+	noAsgs := testComputeProps()
+	noAsgs.AsgCapacityProviders = nil
+
+	duplicateAsgs := testComputeProps()
+	duplicateAsgs.AsgCapacityProviders = append(duplicateAsgs.AsgCapacityProviders, duplicateAsgs.AsgCapacityProviders[0])
+
+	invertedCapacity := testComputeProps().AsgCapacityProviders[0].AutoScalingGroup
+	invertedCapacity.MinCapacity = 3
+
+	longLoadBalancerName := testComputeProps().LoadBalancer
+	longLoadBalancerName.Name = "PublicApplicationLoadBalancerForNginx"
+
+	catchAll := testServiceProps()
+	catchAll.ListenerRule = ContainerServiceListenerRuleProps{}
+
+	fractionalPriority := testServiceProps()
+	fractionalPriority.ListenerRule.Priority = 1.5
+
+	negativeWeight := testServiceProps()
+	negativeWeight.CapacityProviderStrategies[0].Weight = -1
+
+	unknownDefaultStrategy := testComputeProps()
+	unknownDefaultStrategy.Cluster.DefaultCapacityProviderStrategy = []CapacityProviderStrategyProps{{CapacityProvider: FargateCapacityProvider, Weight: 1}}
+
+	mixedStrategy := testServiceProps()
+	mixedStrategy.CapacityProviderStrategies = []CapacityProviderStrategyProps{
+		{CapacityProvider: "AsgCapacityProvider", Base: 1, Weight: 1},
+		{CapacityProvider: FargateSpotCapacityProvider, Base: 1},
+	}
+
+	isolatedAsg := testComputeProps()
+	isolatedAsg.AsgCapacityProviders[0].AutoScalingGroup.SubnetType = ec2.SubnetType_PRIVATE_ISOLATED
+
+	blockedRole := testComputeProps()
+	blockedRole.AsgCapacityProviders[0].CapacityProvider.BlockContainerInstanceRoleAccess = true
+	blockedRole.AsgCapacityProviders[0].AutoScalingGroup.EcsAgent.AllowAwsvpcImds = true
+
+	ebsWithoutZone := testServiceProps()
+	ebsWithoutZone.DesiredCount = 2
+	ebsWithoutZone.Volumes = []DockerVolumeProps{RexrayEbsVolume("data", "/data", 20, "")}
+
+	badZone := testServiceProps()
+	badZone.AvailabilityZone = "us-east"
+
+	longIdleTimeout := testServiceProps()
+	longIdleTimeout.IdleTimeoutSeconds = 4001
+
+	tests := []struct {
+		name  string
+		props validator
+		want  string
+	}{
+		{"compute", &compute, ""},
+		{"compute bad vpc id", &badVpcId, `VpcId "vpc-1" is not a valid VPC ID`},
+		{"compute without asgs", &noAsgs, "AsgCapacityProviders must not be empty when Cluster.IsAsgCapacityProviderEnabled is true"},
+		{"compute unknown default strategy", &unknownDefaultStrategy, `Cluster.DefaultCapacityProviderStrategy[0].CapacityProvider "FARGATE" is not one of the capacity providers on the cluster (AsgCapacityProvider)`},
+		{"compute isolated asg without endpoints", &isolatedAsg, "AsgCapacityProviders[0].AutoScalingGroup.SubnetType PRIVATE_ISOLATED needs VpcEndpoints.Enabled so instances can reach ECS, ECR and CloudWatch Logs"},
+		{"compute blocked role with metadata access", &blockedRole, "AsgCapacityProviders[0].CapacityProvider.BlockContainerInstanceRoleAccess conflicts with AutoScalingGroup.EcsAgent.AllowAwsvpcImds"},
+		{"compute duplicate asgs", &duplicateAsgs, `AsgCapacityProviders[1].AutoScalingGroup.Name "Asg" is used more than once`},
+
+		{"vpc", &ContainerComputeVpcProps{Cidr: "10.0.0.0/16", MaxAzs: 2, NatGateways: 1}, ""},
+		{"vpc bad cidr", &ContainerComputeVpcProps{Cidr: "10.0.0.0/33"}, `Cidr "10.0.0.0/33" is not a valid CIDR block`},
+		{"vpc more nat gateways than zones", &ContainerComputeVpcProps{MaxAzs: 1, NatGateways: 2}, "NatGateways (2) is greater than MaxAzs (1)"},
+
+		{"cluster", &ContainerComputeClusterProps{Name: "dev-cluster"}, ""},
+		{"cluster bad name", &ContainerComputeClusterProps{Name: "dev cluster"}, `Name "dev cluster" may only contain letters, numbers, hyphens and underscores`},
+
+		{"asg inverted capacity", &invertedCapacity, "MinCapacity (3) is greater than MaxCapacity (2)"},
+		{"asg without instance type", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1}, "InstanceClass is required"},
+
+		{"machine image custom", &ContainerComputeMachineImageProps{Family: MachineImageCustom, AmiId: "ami-0123456789abcdef0"}, ""},
+		{"machine image ami without custom", &ContainerComputeMachineImageProps{AmiId: "ami-0123456789abcdef0"}, "AmiId and SsmParameter can only be set when Family is CUSTOM"},
+		{"machine image custom without source", &ContainerComputeMachineImageProps{Family: MachineImageCustom}, "exactly one of AmiId and SsmParameter must be set for a custom image"},
+		{"machine image bad parameter", &ContainerComputeMachineImageProps{Family: MachineImageCustom, SsmParameter: "golden"}, `SsmParameter "golden" must be a parameter path starting with /`},
+		{"machine image unknown family", &ContainerComputeMachineImageProps{Family: "WINDOWS"}, `Family "WINDOWS" is not one of ECS_AL2, ECS_AL2023, BOTTLEROCKET, CUSTOM`},
+
+		{"ecs agent", &EcsAgentConfig{ImagePullBehavior: EcsImagePullAlways, ContainerStopTimeoutSeconds: 30}, ""},
+		{"ecs agent unknown pull behavior", &EcsAgentConfig{ImagePullBehavior: "never"}, `ImagePullBehavior "never" is not one of default, always, once, prefer-cached`},
+		{"ecs agent negative stop timeout", &EcsAgentConfig{ContainerStopTimeoutSeconds: -1}, "ContainerStopTimeoutSeconds (-1) must not be negative"},
+		{"asg nil hook", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, UserDataHooks: []UserDataHook{nil}}, "UserDataHooks[0] is nil"},
+
+		{"asg mixed instances disabled", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MixedInstances: ContainerComputeMixedInstancesProps{SpotPercentage: 50}}, "MixedInstances.Enabled must be true when mixed instances settings are given"},
+		{"asg mixed instances spot percentage", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MixedInstances: ContainerComputeMixedInstancesProps{Enabled: true, SpotPercentage: 101}}, "MixedInstances.SpotPercentage (101) must be between 0 and 100"},
+		{"asg mixed instances architecture", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MixedInstances: ContainerComputeMixedInstancesProps{Enabled: true, InstanceTypes: []ContainerComputeInstanceType{{InstanceClass: ec2.InstanceClass_T4G, InstanceSize: ec2.InstanceSize_MICRO}}}}, "MixedInstances.InstanceTypes[0] T4G.MICRO is ARM_64 but the group's instance type is X86_64"},
+		{"asg mixed instances duplicate", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MixedInstances: ContainerComputeMixedInstancesProps{Enabled: true, InstanceTypes: []ContainerComputeInstanceType{{InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO}}}}, "MixedInstances.InstanceTypes[0] T3.MICRO is listed more than once"},
+
+		{"asg hop limit", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MetadataHopLimit: 65}, "MetadataHopLimit (65) must be between 1 and 64"},
+
+		{"root volume", &ContainerComputeRootVolumeProps{SizeGiB: 100, VolumeType: ec2.EbsDeviceVolumeType_IO2, Iops: 3000}, ""},
+		{"root volume too large", &ContainerComputeRootVolumeProps{SizeGiB: 20000}, "SizeGiB (20000) must be between 1 and 16384"},
+		{"root volume gp2 iops", &ContainerComputeRootVolumeProps{VolumeType: ec2.EbsDeviceVolumeType_GP2, Iops: 3000}, "Iops cannot be set for GP2 volumes"},
+		{"root volume io2 without iops", &ContainerComputeRootVolumeProps{VolumeType: ec2.EbsDeviceVolumeType_IO2}, "Iops is required for IO2 volumes"},
+		{"root volume bad kms key", &ContainerComputeRootVolumeProps{KmsKeyArn: "key"}, `KmsKeyArn "key" is not a valid KMS key ARN`},
+
+		{"access ssh", &InstanceAccessProps{Mode: InstanceAccessSsh, SshKeyName: "ops", SshAllowedCidrs: []string{"10.0.0.0/8"}}, ""},
+		{"access key without ssh", &InstanceAccessProps{SshKeyName: "ops"}, "SshKeyName and SshAllowedCidrs need Mode SSH"},
+		{"access ssh without key", &InstanceAccessProps{Mode: InstanceAccessSsh}, "SshKeyName is required when Mode is SSH"},
+		{"access unknown mode", &InstanceAccessProps{Mode: "TELNET"}, `Mode "TELNET" is not one of SESSION_MANAGER, SSH, NONE`},
+
+		{"session logging", &SessionLoggingProps{DocumentName: "SessionManagerRunShell", LogToS3: true}, ""},
+		{"session logging disabled", &SessionLoggingProps{DocumentName: "SessionManagerRunShell"}, "at least one of LogToS3 and LogToCloudWatch must be set"},
+		{"session logging reserved document name", &SessionLoggingProps{DocumentName: "AWS-RunShell", LogToS3: true}, `DocumentName "AWS-RunShell" must not start with aws or amazon`},
+		{"session logging log group without cloudwatch", &SessionLoggingProps{DocumentName: "Sessions", LogToS3: true, LogGroupName: "/ssm"}, "LogGroupName needs LogToCloudWatch"},
+
+		{"role bad managed policy", &ContainerComputeAsgRoleProps{ManagedPolicyArns: []string{"AdministratorAccess"}}, `ManagedPolicyArns[0] "AdministratorAccess" is not a valid IAM policy ARN`},
+		{"role reserved inline policy", &ContainerComputeAsgRoleProps{InlinePolicies: map[string]iam.PolicyDocument{"Ec2VolumeAccess": iam.NewPolicyDocument(nil)}}, `InlinePolicies name "Ec2VolumeAccess" is reserved`},
+		{"role bad boundary", &ContainerComputeAsgRoleProps{PermissionsBoundaryArn: "boundary"}, `PermissionsBoundaryArn "boundary" is not a valid IAM policy ARN`},
+
+		{"asg plugin setting key", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, VolumePlugins: []DockerVolumePlugin{{Name: "vieux/sshfs", Settings: map[string]string{"sshkey.source": "/root/.ssh"}}}}, `VolumePlugins[0].Settings key "sshkey.source" may only contain upper-case letters, digits and underscores`},
+		{"asg no access on bottlerocket", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MachineImage: ContainerComputeMachineImageProps{Family: MachineImageBottlerocket}, Access: InstanceAccessProps{Mode: InstanceAccessNone}}, "Access.Mode NONE is not possible on BOTTLEROCKET hosts, which always get Session Manager access"},
+		{"asg plugin on bottlerocket", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, MachineImage: ContainerComputeMachineImageProps{Family: MachineImageBottlerocket}, VolumePlugins: []DockerVolumePlugin{{Name: "vieux/sshfs"}}}, "VolumePlugins[0] vieux/sshfs cannot be installed on BOTTLEROCKET hosts"},
+		{"asg rexray on arm64", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T4G, InstanceSize: ec2.InstanceSize_MICRO, VolumePlugins: []DockerVolumePlugin{{Name: RexrayEbsVolumePlugin}}}, "VolumePlugins[0] rexray/ebs is only published for x86_64"},
+		{"asg duplicate plugin driver", &ContainerComputeAsgProps{Name: "Asg", MaxCapacity: 1, InstanceClass: ec2.InstanceClass_T3, InstanceSize: ec2.InstanceSize_MICRO, VolumePlugins: []DockerVolumePlugin{{Name: "vieux/sshfs"}, {Name: "other/sshfs", Alias: "vieux/sshfs"}}}, `VolumePlugins[1] driver "vieux/sshfs" is already installed`},
+
+		{"volume", &DockerVolumeProps{Name: "data", ContainerPath: "/data"}, ""},
+		{"volume relative path", &DockerVolumeProps{Name: "data", ContainerPath: "data"}, `ContainerPath "data" must be an absolute path`},
+		{"volume autoprovision without shared scope", &DockerVolumeProps{Name: "data", ContainerPath: "/data", Autoprovision: true}, "Autoprovision needs Scope SHARED"},
+
+		{"capacity provider", &ContainerComputeAsgCapacityProviderProps{Name: "Ec2"}, ""},
+		{"capacity provider target capacity", &ContainerComputeAsgCapacityProviderProps{Name: "Ec2", TargetCapacityPercent: 120}, "TargetCapacityPercent (120) must be between 1 and 100"},
+		{"capacity provider inverted step sizes", &ContainerComputeAsgCapacityProviderProps{Name: "Ec2", MinimumScalingStepSize: 5, MaximumScalingStepSize: 2}, "MinimumScalingStepSize (5) is greater than MaximumScalingStepSize (2)"},
+		{"capacity provider warmup", &ContainerComputeAsgCapacityProviderProps{Name: "Ec2", InstanceWarmupSeconds: 10001}, "InstanceWarmupSeconds (10001) must be between 0 and 10000"},
+		{"capacity provider reserved prefix", &ContainerComputeAsgCapacityProviderProps{Name: "FargateLike"}, `Name "FargateLike" must not start with "fargate"`},
+
+		{"load balancer long name", &longLoadBalancerName, `Name "PublicApplicationLoadBalancerForNginx" is 37 characters, the maximum is 32`},
+		{"load balancer without certificate", &ContainerComputeLoadBalancerProps{Name: "Alb"}, "one of ListenerCertificateArn, CertificateArns and CertificateDomainNames is required"},
+		{"load balancer bad certificate", &ContainerComputeLoadBalancerProps{Name: "Alb", ListenerCertificateArn: "cert"}, `ListenerCertificateArn "cert" is not a valid ACM certificate ARN`},
+
+		{"load balancer private subnets", &ContainerComputeLoadBalancerProps{Name: "Alb", SubnetType: ec2.SubnetType_PRIVATE_WITH_EGRESS}, "SubnetType PRIVATE_WITH_EGRESS cannot be used for an internet-facing load balancer, set Internal or use PUBLIC subnets"},
+		{"load balancer subnet ids and type", &ContainerComputeLoadBalancerProps{Name: "Alb", Internal: true, SubnetIds: []string{"subnet-0123456789abcdef0"}, SubnetType: ec2.SubnetType_PRIVATE_ISOLATED}, "SubnetIds and SubnetType are mutually exclusive"},
+		{"load balancer bad subnet id", &ContainerComputeLoadBalancerProps{Name: "Alb", SubnetIds: []string{"subnet-1"}}, `SubnetIds[0] "subnet-1" is not a valid subnet ID`},
+		{"load balancer ipv6 cidr", &ContainerComputeLoadBalancerProps{Name: "Alb", AllowedCidrs: []string{"2001:db8::/32"}}, `AllowedCidrs[0] "2001:db8::/32" is not a valid IPv4 CIDR block`},
+		{"load balancer bad prefix list", &ContainerComputeLoadBalancerProps{Name: "Alb", AllowedPrefixLists: []string{"prefix"}}, `AllowedPrefixLists[0] "prefix" is not a valid prefix list ID`},
+
+		{"load balancer bad sni certificate", &ContainerComputeLoadBalancerProps{Name: "Alb", CertificateArns: []string{"certificate"}}, `CertificateArns[0] "certificate" is not a valid ACM certificate ARN`},
+		{"load balancer domains without zone", &ContainerComputeLoadBalancerProps{Name: "Alb", CertificateDomainNames: []string{"api.example.com"}}, "CertificateDomainNames need HostedZone.Name for DNS validation"},
+		{"load balancer domain outside zone", &ContainerComputeLoadBalancerProps{Name: "Alb", HostedZone: ContainerComputeHostedZoneProps{Name: "example.com"}, CertificateDomainNames: []string{"api.example.org"}}, `CertificateDomainNames[0] "api.example.org" is not in hosted zone example.com`},
+		{"load balancer upper-case domain", &ContainerComputeLoadBalancerProps{Name: "Alb", HostedZone: ContainerComputeHostedZoneProps{Name: "example.com"}, CertificateDomainNames: []string{"API.example.com"}}, `CertificateDomainNames[0] "API.example.com" is not a valid lower-case domain name`},
+		{"load balancer duplicate domain", &ContainerComputeLoadBalancerProps{Name: "Alb", HostedZone: ContainerComputeHostedZoneProps{Name: "example.com."}, CertificateDomainNames: []string{"*.example.com", "*.example.com"}}, `CertificateDomainNames[1] "*.example.com" is listed more than once`},
+		{"load balancer zone id without name", &ContainerComputeLoadBalancerProps{Name: "Alb", ListenerCertificateArn: testCertificateArn, HostedZone: ContainerComputeHostedZoneProps{Id: "Z0123456789ABCDEFGHIJ"}}, "HostedZone.Name is required when HostedZone.Id is set"},
+		{"load balancer bad zone id", &ContainerComputeLoadBalancerProps{Name: "Alb", ListenerCertificateArn: testCertificateArn, HostedZone: ContainerComputeHostedZoneProps{Id: "zone", Name: "example.com"}}, `HostedZone.Id "zone" is not a valid hosted zone ID`},
+
+		{"load balancer idle timeout", &ContainerComputeLoadBalancerProps{Name: "Alb", ListenerCertificateArn: testCertificateArn, Hardening: ContainerComputeLoadBalancerHardeningProps{IdleTimeoutSeconds: -1}}, "Hardening.IdleTimeoutSeconds (-1) must be between 1 and 4000"},
+
+		{"access logs without enabled", &ContainerComputeAccessLogsProps{Prefix: "alb"}, "BucketName, Prefix and the lifecycle days need Enabled"},
+		{"access logs bad bucket name", &ContainerComputeAccessLogsProps{Enabled: true, BucketName: "Central_Logs"}, `BucketName "Central_Logs" must be 3 to 63 lowercase letters, digits, '.' or '-'`},
+		{"access logs lifecycle on existing bucket", &ContainerComputeAccessLogsProps{Enabled: true, BucketName: "central-alb-logs", ExpirationDays: 30}, `lifecycle days only apply to a created bucket, not to BucketName "central-alb-logs"`},
+		{"access logs bad prefix", &ContainerComputeAccessLogsProps{Enabled: true, Prefix: "alb/"}, `Prefix "alb/" must not start or end with '/' or contain '//'`},
+		{"access logs AWSLogs prefix", &ContainerComputeAccessLogsProps{Enabled: true, Prefix: "AWSLogs"}, `Prefix "AWSLogs" must not contain AWSLogs`},
+		{"access logs early infrequent access", &ContainerComputeAccessLogsProps{Enabled: true, InfrequentAccessAfterDays: 7}, "InfrequentAccessAfterDays (7) must be at least 30"},
+		{"access logs glacier too soon after infrequent access", &ContainerComputeAccessLogsProps{Enabled: true, InfrequentAccessAfterDays: 30, GlacierAfterDays: 45}, "GlacierAfterDays (45) must be at least 30 days after InfrequentAccessAfterDays (30)"},
+		{"access logs expiration before transition", &ContainerComputeAccessLogsProps{Enabled: true, GlacierAfterDays: 90, ExpirationDays: 60}, "ExpirationDays (60) must be later than the last transition (90)"},
+
+		{"default action fixed response", &ContainerComputeDefaultActionProps{StatusCode: 503, ContentType: "application/json", MessageBody: "{}"}, ""},
+		{"default action redirect status code", &ContainerComputeDefaultActionProps{StatusCode: 301}, "StatusCode (301) must be a 2XX, 4XX or 5XX code"},
+		{"default action bad content type", &ContainerComputeDefaultActionProps{ContentType: "text/xml"}, `ContentType "text/xml" is not supported by fixed responses`},
+		{"default action fixed response with host", &ContainerComputeDefaultActionProps{RedirectHost: "example.com"}, "only StatusCode, ContentType and MessageBody apply to FIXED_RESPONSE"},
+		{"default action redirect loop", &ContainerComputeDefaultActionProps{Type: DefaultActionRedirect, RedirectQuery: "a=b"}, "at least one of RedirectHost, RedirectPath, RedirectPort and RedirectProtocol is required"},
+		{"default action redirect protocol", &ContainerComputeDefaultActionProps{Type: DefaultActionRedirect, RedirectProtocol: "FTP"}, `RedirectProtocol "FTP" is not one of HTTP, HTTPS`},
+		{"default action redirect path", &ContainerComputeDefaultActionProps{Type: DefaultActionRedirect, RedirectPath: "home"}, `RedirectPath "home" must start with /`},
+		{"default action forward without service", &ContainerComputeDefaultActionProps{Type: DefaultActionForward}, "ServiceName is required"},
+		{"default action forward with status code", &ContainerComputeDefaultActionProps{Type: DefaultActionForward, ServiceName: "Nginx", StatusCode: 200}, "only ServiceName, HealthCheckPath and HealthyHttpCodes apply to FORWARD"},
+		{"default action forward health check path", &ContainerComputeDefaultActionProps{Type: DefaultActionForward, ServiceName: "Nginx", HealthCheckPath: "health"}, `HealthCheckPath "health" must start with /`},
+		{"default action health check without forward", &ContainerComputeDefaultActionProps{HealthCheckPath: "/health"}, "only StatusCode, ContentType and MessageBody apply to FIXED_RESPONSE"},
+		{"default action unknown type", &ContainerComputeDefaultActionProps{Type: "DROP"}, "Type \"DROP\" is not one of FIXED_RESPONSE, REDIRECT, FORWARD"},
+
+		{"cloudmap namespace without name", &ContainerComputeCloudmapNamespaceProps{}, "Name is required"},
+
+		{"service", &service, ""},
+		{"service catch-all rule", &catchAll, "ListenerRule needs HostHeaders or PathPatterns"},
+		{"service fractional priority", &fractionalPriority, "ListenerRule.Priority (1.5) must be a whole number between 1 and 50000, or 0 to have one assigned"},
+		{"service negative weight", &negativeWeight, "CapacityProviderStrategies[0].Weight (-1) must be between 0 and 1000"},
+		{"service mixed strategy", &mixedStrategy, "CapacityProviderStrategies: cannot mix Fargate and Auto Scaling group capacity providers in one strategy"},
+		{"service strategy with two bases", &mixedStrategy, "CapacityProviderStrategies: only one capacity provider may have a Base"},
+		{"service ebs volume without zone", &ebsWithoutZone, "AvailabilityZone is required for services with EBS volumes"},
+		{"service ebs volume with two tasks", &ebsWithoutZone, "DesiredCount (2) must be at most 1 for services with EBS volumes"},
+		{"service idle timeout", &longIdleTimeout, "IdleTimeoutSeconds (4001) must be between 1 and 4000"},
+		{"service bad zone", &badZone, `AvailabilityZone "us-east" is not a valid availability zone name`},
+
+		{"container without port", &ContainerServiceContainerProps{Name: "nginx", Image: "nginx", MemoryLimitMiB: 512}, "ContainerPort (0) must be between 1 and 65535"},
+		{"container without memory", &ContainerServiceContainerProps{Name: "nginx", Image: "nginx", ContainerPort: 80}, "MemoryLimitMiB (0) must be greater than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.props.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			if !containsError(validationErr.Errors, tt.want) {
+				t.Errorf("Validate() errors = %q, want %q", validationErr.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	got := withPrefix("Cluster", []string{"Name is required", "[0].Weight (-1) must be between 0 and 1000", "at least one capacity provider must have a Weight greater than zero"})
+	want := []string{"Cluster.Name is required", "Cluster[0].Weight (-1) must be between 0 and 1000", "Cluster: at least one capacity provider must have a Weight greater than zero"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withPrefix() = %q, want %q", got, want)
+	}
+}
+
+func containsError(errs []string, want string) bool {
+	for _, err := range errs {
+		if err == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidationFailsSynth(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	props := testComputeProps()
+	props.Cluster.Name = ""
+	NewContainerCompute(stack, jsii.String("Compute"), &props)
+
+	// THEN
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "Cluster.Name is required") {
+			t.Errorf("Synth() panic = %v, want Cluster.Name is required", r)
+		}
+	}()
+
+	// WHEN
+	awscdk.Stage_Of(stack).Synth(nil)
+}
@@ -0,0 +1,180 @@
+package breezeware
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	ecs "github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	RexrayEbsVolumePlugin = "rexray/ebs"
+	LocalVolumeDriver     = "local"
+)
+
+// DockerVolumePlugin is a Docker managed plugin installed on the hosts of an
+// auto scaling group. Tasks refer to it by Alias, which defaults to Name.
+// Settings are passed to docker plugin install as KEY=VALUE pairs. Keys are
+// upper-case environment variable names; values are shell-quoted.
+type DockerVolumePlugin struct {
+	Name     string
+	Alias    string
+	Settings map[string]string
+}
+
+func (p *DockerVolumePlugin) driver() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	return p.Name
+}
+
+// installCommand fills in the region and preemption settings rexray/ebs needs
+// to move volumes between hosts.
+func (p *DockerVolumePlugin) installCommand() string {
+	settings := make(map[string]string)
+	if p.Name == RexrayEbsVolumePlugin {
+		settings["REXRAY_PREEMPT"] = "true"
+		settings["EBS_REGION"] = *awscdk.Aws_REGION()
+	}
+	for key, value := range p.Settings {
+		settings[key] = value
+	}
+
+	command := []string{"docker plugin install --grant-all-permissions"}
+	if p.Alias != "" {
+		command = append(command, "--alias", p.Alias)
+	}
+	command = append(command, p.Name)
+	for _, key := range sortedKeys(settings) {
+		command = append(command, key+"="+shellQuote(settings[key]))
+	}
+	return strings.Join(command, " ")
+}
+
+// shellQuote wraps value in single quotes so user data runs it as one word.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func volumePluginCommands(props *ContainerComputeAsgProps) []string {
+	var commands []string
+	for i := range props.VolumePlugins {
+		commands = append(commands, props.VolumePlugins[i].installCommand())
+	}
+	return commands
+}
+
+// volumeDrivers lists the drivers tasks can use on the group's hosts.
+func volumeDrivers(props *ContainerComputeAsgProps) []string {
+	drivers := []string{LocalVolumeDriver}
+	for i := range props.VolumePlugins {
+		drivers = append(drivers, props.VolumePlugins[i].driver())
+	}
+	sort.Strings(drivers)
+	return drivers
+}
+
+// rexrayEbsPluginEnabled reports whether the group's hosts get the rexray/ebs
+// plugin and so need EBS volume permissions on their role.
+func rexrayEbsPluginEnabled(props *ContainerComputeAsgProps) bool {
+	for _, plugin := range props.VolumePlugins {
+		if plugin.Name == RexrayEbsVolumePlugin {
+			return true
+		}
+	}
+	return false
+}
+
+// DockerVolumeProps declares a Docker volume on a task definition and mounts
+// it into the service's container at ContainerPath. Driver defaults to local
+// and Scope to task. Autoprovision creates a missing shared volume.
+type DockerVolumeProps struct {
+	Name          string
+	ContainerPath string
+	ReadOnly      bool
+	Driver        string
+	DriverOptions map[string]string
+	Labels        map[string]string
+	Scope         ecs.Scope
+	Autoprovision bool
+}
+
+// RexrayEbsVolume returns a shared, autoprovisioned rexray/ebs volume of
+// sizeGiB. The volume lives in one availability zone.
+func RexrayEbsVolume(name, containerPath string, sizeGiB float64, volumeType string) DockerVolumeProps {
+	driverOptions := map[string]string{"size": strconv.FormatFloat(sizeGiB, 'f', -1, 64)}
+	if volumeType != "" {
+		driverOptions["volumetype"] = volumeType
+	}
+	return DockerVolumeProps{
+		Name:          name,
+		ContainerPath: containerPath,
+		Driver:        RexrayEbsVolumePlugin,
+		DriverOptions: driverOptions,
+		Scope:         ecs.Scope_SHARED,
+		Autoprovision: true,
+	}
+}
+
+func (p *DockerVolumeProps) driver() string {
+	if p.Driver == "" {
+		return LocalVolumeDriver
+	}
+	return p.Driver
+}
+
+// zonal reports whether the volume is an EBS volume tied to one availability
+// zone.
+func (p *DockerVolumeProps) zonal() bool {
+	return p.driver() == RexrayEbsVolumePlugin
+}
+
+// DockerVolume converts props to a task definition volume.
+func DockerVolume(props *DockerVolumeProps) *ecs.Volume {
+	scope := props.Scope
+	if scope == "" {
+		scope = ecs.Scope_TASK
+	}
+
+	configuration := &ecs.DockerVolumeConfiguration{
+		Driver: jsii.String(props.driver()),
+		Scope:  scope,
+	}
+	if scope == ecs.Scope_SHARED {
+		configuration.Autoprovision = jsii.Bool(props.Autoprovision)
+	}
+	if len(props.DriverOptions) > 0 {
+		configuration.DriverOpts = stringMap(props.DriverOptions)
+	}
+	if len(props.Labels) > 0 {
+		configuration.Labels = stringMap(props.Labels)
+	}
+
+	return &ecs.Volume{
+		Name:                      jsii.String(props.Name),
+		DockerVolumeConfiguration: configuration,
+	}
+}
+
+// AddDockerVolume adds the volume to taskDefinition and mounts it into
+// container.
+func AddDockerVolume(taskDefinition ecs.TaskDefinition, container ecs.ContainerDefinition, props *DockerVolumeProps) {
+	taskDefinition.AddVolume(DockerVolume(props))
+	container.AddMountPoints(&ecs.MountPoint{
+		SourceVolume:  jsii.String(props.Name),
+		ContainerPath: jsii.String(props.ContainerPath),
+		ReadOnly:      jsii.Bool(props.ReadOnly),
+	})
+}
+
+func stringMap(values map[string]string) *map[string]*string {
+	m := make(map[string]*string)
+	for key, value := range values {
+		m[key] = jsii.String(value)
+	}
+	return &m
+}
@@ -0,0 +1,107 @@
+package breezeware
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestDockerVolumes(t *testing.T) {
+	// GIVEN
+	stack := newTestStack()
+	computeProps := testComputeProps()
+	computeProps.AsgCapacityProviders[0].AutoScalingGroup.VolumePlugins = []DockerVolumePlugin{{Name: RexrayEbsVolumePlugin}}
+	compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+	props := testServiceProps()
+	props.AvailabilityZone = "us-east-1a"
+	props.Volumes = []DockerVolumeProps{
+		RexrayEbsVolume("data", "/data", 20, "gp3"),
+		{Name: "scratch", ContainerPath: "/scratch", ReadOnly: true, Labels: map[string]string{"team": "web"}},
+	}
+
+	// WHEN
+	NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+	// THEN
+	if got, want := compute.CapacityProviderVolumeDrivers()["AsgCapacityProvider"], []string{"local", "rexray/ebs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CapacityProviderVolumeDrivers() = %q, want %q", got, want)
+	}
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+		"Volumes": []interface{}{
+			map[string]interface{}{
+				"Name": "data",
+				"DockerVolumeConfiguration": map[string]interface{}{
+					"Driver":        "rexray/ebs",
+					"Scope":         "shared",
+					"Autoprovision": true,
+					"DriverOpts":    map[string]interface{}{"size": "20", "volumetype": "gp3"},
+				},
+			},
+			map[string]interface{}{
+				"Name": "scratch",
+				"DockerVolumeConfiguration": map[string]interface{}{
+					"Driver": "local",
+					"Scope":  "task",
+					"Labels": map[string]interface{}{"team": "web"},
+				},
+			},
+		},
+		"ContainerDefinitions": []interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{
+				"MountPoints": []interface{}{
+					map[string]interface{}{"SourceVolume": "data", "ContainerPath": "/data", "ReadOnly": false},
+					map[string]interface{}{"SourceVolume": "scratch", "ContainerPath": "/scratch", "ReadOnly": true},
+				},
+			}),
+		},
+	})
+}
+
+func TestDockerVolumeDriverMissing(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategies []CapacityProviderStrategyProps
+		want       string
+	}{
+		{
+			name:       "service strategy",
+			strategies: []CapacityProviderStrategyProps{{CapacityProvider: "AsgCapacityProvider", Weight: 1}},
+			want:       `CapacityProviderStrategies[0] AsgCapacityProvider has no "rexray/ebs" volume driver for volume "data"`,
+		},
+		{
+			name: "cluster default strategy",
+			want: `cluster DefaultCapacityProviderStrategy[0] AsgCapacityProvider has no "rexray/ebs" volume driver for volume "data"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			stack := newTestStack()
+			computeProps := testComputeProps()
+			computeProps.Cluster.DefaultCapacityProviderStrategy = []CapacityProviderStrategyProps{{CapacityProvider: "AsgCapacityProvider", Weight: 1}}
+			compute := NewContainerCompute(stack, jsii.String("Compute"), &computeProps)
+			props := testServiceProps()
+			props.CapacityProviderStrategies = tt.strategies
+			props.AvailabilityZone = "us-east-1a"
+			props.Volumes = []DockerVolumeProps{RexrayEbsVolume("data", "/data", 20, "")}
+			NewContainerService(stack, jsii.String("Service"), compute, &props)
+
+			// THEN
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), tt.want) {
+					t.Errorf("Synth() panic = %v, want %s", r, tt.want)
+				}
+			}()
+
+			// WHEN
+			awscdk.Stage_Of(stack).Synth(nil)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	clusterConstruct "cdk-consrtuct/compute-construct"
+
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
@@ -93,16 +95,20 @@ func CdkConsrtuctStack(scope constructs.Construct, id string, props *CdkConsrtuc
 
 	securityGroup := awsec2.SecurityGroup_FromLookupById(stack, jsii.String(sgName), jsii.String(sgId))
 
+	access := clusterConstruct.InstanceAccessProps{Mode: clusterConstruct.InstanceAccessSessionManager}
+
 	ec2Instance := awsec2.NewInstance(stack, jsii.String("InstanceBrz"), &awsec2.InstanceProps{
 		Vpc: vpc, PropagateTagsToVolumeOnCreation: jsii.Bool(true),
 		InstanceType:  awsec2.InstanceType_Of(awsec2.InstanceClass_BURSTABLE2, awsec2.InstanceSize_MICRO),
 		MachineImage:  image,
-		KeyName:       jsii.String("breezethru-demo-key-pair"),
+		KeyName:       access.KeyName(),
 		SecurityGroup: securityGroup,
 		InstanceName:  jsii.String("brz-demo-" + regionName),
 		UserData:      awsec2.UserData_ForLinux(&awsec2.LinuxUserDataOptions{Shebang: jsii.String("#!/bin/bash")}),
 	})
 
+	clusterConstruct.ConfigureInstanceAccess(ec2Instance.Role(), securityGroup, &access)
+
 	var clusterName = "golang-demo-devus-east-1"
 
 	ec2Instance.UserData().AddCommands(
@@ -3,10 +3,10 @@ module cdk-consrtuct
 go 1.18
 
 require (
-	github.com/Breezeware-Technologies/breezeware-aws-cdk-patterns v1.0.0
 	github.com/aws/aws-cdk-go/awscdk/v2 v2.61.1
 	github.com/aws/constructs-go/constructs/v10 v10.1.228
 	github.com/aws/jsii-runtime-go v1.73.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (